@@ -0,0 +1,92 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package clienttest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+)
+
+// Account is an account of the in-memory chain, it holds the
+// private key used to sign the transactions it sends
+type Account struct {
+	Address string
+	key     ed25519.PrivateKey
+}
+
+// NewAccount returns the account whose key is derived deterministically from seed
+func NewAccount(seed string) *Account {
+	s := sha256.Sum256([]byte(seed))
+	key := ed25519.NewKeyFromSeed(s[:])
+	return &Account{
+		Address: addressFromPubKey(key.Public().(ed25519.PublicKey)),
+		key:     key,
+	}
+}
+
+// Identifier returns the rosetta account identifier of the account
+func (a *Account) Identifier() *types.AccountIdentifier {
+	return &types.AccountIdentifier{Address: a.Address}
+}
+
+// PublicKey returns the rosetta public key of the account
+func (a *Account) PublicKey() *types.PublicKey {
+	return &types.PublicKey{
+		Bytes:     []byte(a.key.Public().(ed25519.PublicKey)),
+		CurveType: types.Edwards25519,
+	}
+}
+
+// Sign signs the payload returned by /construction/payloads, it implements conformance.Signer.
+// Payloads requesting a signature type other than ed25519 are rejected.
+func (a *Account) Sign(payload *types.SigningPayload) (*types.Signature, error) {
+	if payload.SignatureType != "" && payload.SignatureType != types.Ed25519 {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("unsupported signature type %s", payload.SignatureType))
+	}
+	return &types.Signature{
+		SigningPayload: payload,
+		PublicKey:      a.PublicKey(),
+		SignatureType:  types.Ed25519,
+		Bytes:          a.sign(payload.Bytes),
+	}, nil
+}
+
+func (a *Account) sign(b []byte) []byte {
+	return ed25519.Sign(a.key, b)
+}
+
+// addressFromPubKey derives the address as the hex encoding of the first 20 bytes of the public key sha256
+func addressFromPubKey(pubKey ed25519.PublicKey) string {
+	hash := sha256.Sum256(pubKey)
+	return hex.EncodeToString(hash[:20])
+}
+
+// validateAddress checks the address has the format returned by addressFromPubKey
+func validateAddress(address string) error {
+	b, err := hex.DecodeString(address)
+	if err != nil || len(b) != 20 {
+		return crgerrs.WrapError(crgerrs.ErrInvalidAddress, "invalid address "+address)
+	}
+	return nil
+}
@@ -0,0 +1,656 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package clienttest provides an in-memory chain implementing the gateway Client.
+// It allows to test integrations end-to-end, including over HTTP through NewHTTPServer,
+// without running a node: blocks, balances, the mempool, peers and the sync status
+// are programmed by the test, and transactions are simple signed transfers.
+package clienttest
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// MetadataSequence is the construction metadata key containing the sender sequence
+const MetadataSequence = "sequence"
+
+// OptionFrom is the preprocess option key containing the sender address
+const OptionFrom = "from"
+
+// Config defines the in-memory chain parameters, zero values are replaced by defaults
+type Config struct {
+	// Network identifies the chain, defaults to clienttest/testnet
+	Network *types.NetworkIdentifier
+	// Currency is the only currency of the chain, defaults to stake with zero decimals
+	Currency *types.Currency
+	// GenesisTime is the timestamp of the genesis block
+	GenesisTime time.Time
+	// BlockTime is the interval between the timestamps of consecutive blocks, defaults to 5s
+	BlockTime time.Duration
+	// Version is the node version reported by the client
+	Version string
+}
+
+func (c Config) withDefaults() Config {
+	if c.Network == nil {
+		c.Network = &types.NetworkIdentifier{Blockchain: "clienttest", Network: "testnet"}
+	}
+	if c.Currency == nil {
+		c.Currency = &types.Currency{Symbol: "stake"}
+	}
+	if c.GenesisTime.IsZero() {
+		c.GenesisTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if c.BlockTime == 0 {
+		c.BlockTime = 5 * time.Second
+	}
+	if c.Version == "" {
+		c.Version = "clienttest"
+	}
+	return c
+}
+
+type block struct {
+	identifier *types.BlockIdentifier
+	parent     *types.BlockIdentifier
+	timestamp  int64
+	txs        []*committedTx
+}
+
+type committedTx struct {
+	hash   string
+	tx     *Tx
+	block  *types.BlockIdentifier
+	status string
+}
+
+type pendingTx struct {
+	hash  string
+	bytes []byte
+	tx    *Tx
+}
+
+// balance is the balance of an account from height onwards
+type balance struct {
+	height int64
+	amount *big.Int
+}
+
+// Client is an in-memory chain implementing types.Client, it is safe for concurrent use.
+// Transaction hashes are upper hex and, as for a node, queried case insensitively.
+type Client struct {
+	config Config
+
+	mu           sync.RWMutex
+	blocks       []*block // blocks[i] has height i+1
+	blocksByHash map[string]*block
+	txs          map[string]*committedTx
+	balances     map[string][]balance
+	sequences    map[string]uint64
+	mempool      []*pendingTx
+	peers        []*types.Peer
+	syncStatus   *types.SyncStatus
+	readyErr     error
+	// committed is closed and replaced every time a block is committed
+	committed chan struct{}
+}
+
+var (
+	_ crgtypes.Client                  = (*Client)(nil)
+	_ crgtypes.PeerProvider            = (*Client)(nil)
+	_ crgtypes.MempoolProvider         = (*Client)(nil)
+	_ crgtypes.UnconfirmedTxProvider   = (*Client)(nil)
+	_ crgtypes.MempoolTxsProvider      = (*Client)(nil)
+	_ crgtypes.TxBlockProvider         = (*Client)(nil)
+	_ crgtypes.TxInclusionWaiter       = (*Client)(nil)
+	_ crgtypes.OperationSchemaProvider = (*Client)(nil)
+)
+
+// NewClient returns the in-memory chain with its genesis block at height 1
+func NewClient(config Config) *Client {
+	c := &Client{
+		config:       config.withDefaults(),
+		blocksByHash: make(map[string]*block),
+		txs:          make(map[string]*committedTx),
+		balances:     make(map[string][]balance),
+		sequences:    make(map[string]uint64),
+		peers:        []*types.Peer{},
+		committed:    make(chan struct{}),
+	}
+	c.commit()
+	return c
+}
+
+// Network returns the network identifier of the chain
+func (c *Client) Network() *types.NetworkIdentifier {
+	return c.config.Network
+}
+
+// Currency returns the currency of the chain
+func (c *Client) Currency() *types.Currency {
+	return c.config.Currency
+}
+
+// SetBalance sets the balance of address at the latest height, the balances
+// at previous heights are unchanged
+func (c *Client) SetBalance(address string, amount *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setBalance(address, c.latest().identifier.Index, new(big.Int).Set(amount))
+}
+
+// SetPeers sets the peers returned by Peers
+func (c *Client) SetPeers(peers ...*types.Peer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers = peers
+}
+
+// SetSyncStatus sets the sync status returned by Status
+func (c *Client) SetSyncStatus(status *types.SyncStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.syncStatus = status
+}
+
+// SetReady sets the error returned by Ready, nil makes the client ready
+func (c *Client) SetReady(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readyErr = err
+}
+
+// CommitBlock commits a new block including every transaction in the mempool
+// and returns its identifier. Transactions whose sender cannot afford the
+// transfer are included with failed status.
+func (c *Client) CommitBlock() *types.BlockIdentifier {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.commit().identifier
+}
+
+func (c *Client) commit() *block {
+	height := int64(len(c.blocks)) + 1
+	b := &block{
+		timestamp: c.config.GenesisTime.Add(time.Duration(height-1)*c.config.BlockTime).UnixNano() / int64(time.Millisecond),
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d", height)
+	if height > 1 {
+		b.parent = c.latest().identifier
+		fmt.Fprintf(hash, "/%s", b.parent.Hash)
+	}
+	for _, p := range c.mempool {
+		fmt.Fprintf(hash, "/%s", p.hash)
+	}
+	b.identifier = &types.BlockIdentifier{
+		Index: height,
+		Hash:  strings.ToUpper(fmt.Sprintf("%x", hash.Sum(nil))),
+	}
+	// the genesis block is its own parent
+	if b.parent == nil {
+		b.parent = b.identifier
+	}
+
+	for _, p := range c.mempool {
+		status := StatusSuccess
+		from := c.balanceAt(p.tx.From, height)
+		amount := p.tx.amount()
+		if from.Cmp(amount) < 0 {
+			status = StatusFailure
+		} else {
+			c.setBalance(p.tx.From, height, new(big.Int).Sub(from, amount))
+			c.setBalance(p.tx.To, height, new(big.Int).Add(c.balanceAt(p.tx.To, height), amount))
+		}
+		c.sequences[p.tx.From]++
+		ctx := &committedTx{hash: p.hash, tx: p.tx, block: b.identifier, status: status}
+		b.txs = append(b.txs, ctx)
+		c.txs[p.hash] = ctx
+	}
+	c.mempool = nil
+
+	c.blocks = append(c.blocks, b)
+	c.blocksByHash[b.identifier.Hash] = b
+	close(c.committed)
+	c.committed = make(chan struct{})
+	return b
+}
+
+func (c *Client) latest() *block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *Client) setBalance(address string, height int64, amount *big.Int) {
+	history := c.balances[address]
+	if n := len(history); n != 0 && history[n-1].height == height {
+		history[n-1].amount = amount
+		return
+	}
+	c.balances[address] = append(history, balance{height: height, amount: amount})
+}
+
+func (c *Client) balanceAt(address string, height int64) *big.Int {
+	history := c.balances[address]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].height <= height {
+			return history[i].amount
+		}
+	}
+	return new(big.Int)
+}
+
+// nextSequence returns the sequence expected for the next transaction of address,
+// accounting for the transactions in the mempool
+func (c *Client) nextSequence(address string) uint64 {
+	seq := c.sequences[address]
+	for _, p := range c.mempool {
+		if p.tx.From == address {
+			seq++
+		}
+	}
+	return seq
+}
+
+func (c *Client) blockAt(height *int64) (*block, error) {
+	if height == nil {
+		return c.latest(), nil
+	}
+	if *height < 1 || *height > int64(len(c.blocks)) {
+		return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("block at height %d not found", *height))
+	}
+	return c.blocks[*height-1], nil
+}
+
+func (c *Client) blockByHash(hash string) (*block, error) {
+	b, ok := c.blocksByHash[hash]
+	if !ok {
+		return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("block %s not found", hash))
+	}
+	return b, nil
+}
+
+func (b *block) response() crgtypes.BlockResponse {
+	return crgtypes.BlockResponse{
+		Block:                b.identifier,
+		ParentBlock:          b.parent,
+		MillisecondTimestamp: b.timestamp,
+		TxCount:              int64(len(b.txs)),
+	}
+}
+
+func (c *Client) blockTransactions(b *block) crgtypes.BlockTransactionsResponse {
+	txs := make([]*types.Transaction, len(b.txs))
+	for i, tx := range b.txs {
+		txs[i] = c.transaction(tx.hash, tx.tx, tx.status)
+	}
+	return crgtypes.BlockTransactionsResponse{
+		BlockResponse: b.response(),
+		Transactions:  txs,
+	}
+}
+
+func (c *Client) transaction(hash string, tx *Tx, status string) *types.Transaction {
+	return &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		Operations:            tx.operations(c.config.Currency, status),
+	}
+}
+
+func (c *Client) Bootstrap() error {
+	return nil
+}
+
+func (c *Client) Ready() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.readyErr
+}
+
+// Balances returns the balance of addr at height, nil or zero height means the latest block
+func (c *Client) Balances(_ context.Context, addr string, height *int64) ([]*types.Amount, error) {
+	if err := validateAddress(addr); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height != nil && *height == 0 {
+		height = nil
+	}
+	b, err := c.blockAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return []*types.Amount{{
+		Value:    c.balanceAt(addr, b.identifier.Index).String(),
+		Currency: c.config.Currency,
+	}}, nil
+}
+
+func (c *Client) BlockByHash(_ context.Context, hash string) (crgtypes.BlockResponse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, err := c.blockByHash(hash)
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	return b.response(), nil
+}
+
+func (c *Client) BlockByHeight(_ context.Context, height *int64) (crgtypes.BlockResponse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, err := c.blockAt(height)
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	return b.response(), nil
+}
+
+func (c *Client) BlockTransactionsByHash(_ context.Context, hash string) (crgtypes.BlockTransactionsResponse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, err := c.blockByHash(hash)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, err
+	}
+	return c.blockTransactions(b), nil
+}
+
+func (c *Client) BlockTransactionsByHeight(_ context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	b, err := c.blockAt(height)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, err
+	}
+	return c.blockTransactions(b), nil
+}
+
+func (c *Client) GetTx(_ context.Context, hash string) (*types.Transaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	tx, ok := c.txs[strings.ToUpper(hash)]
+	if !ok {
+		return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("tx %s not found", hash))
+	}
+	return c.transaction(tx.hash, tx.tx, tx.status), nil
+}
+
+func (c *Client) TxBlock(_ context.Context, hash string) (*types.BlockIdentifier, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	tx, ok := c.txs[strings.ToUpper(hash)]
+	if !ok {
+		return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("tx %s not found", hash))
+	}
+	return tx.block, nil
+}
+
+func (c *Client) GetUnconfirmedTx(_ context.Context, hash string) (*types.Transaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, p := range c.mempool {
+		if strings.EqualFold(p.hash, hash) {
+			return c.transaction(p.hash, p.tx, ""), nil
+		}
+	}
+	return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("tx %s not found in mempool", hash))
+}
+
+func (c *Client) Mempool(_ context.Context) ([]*types.TransactionIdentifier, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	txs := make([]*types.TransactionIdentifier, len(c.mempool))
+	for i, p := range c.mempool {
+		txs[i] = &types.TransactionIdentifier{Hash: p.hash}
+	}
+	return txs, nil
+}
+
+func (c *Client) MempoolTxs(_ context.Context) ([]crgtypes.MempoolTx, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	txs := make([]crgtypes.MempoolTx, len(c.mempool))
+	for i, p := range c.mempool {
+		txs[i] = crgtypes.MempoolTx{Bytes: p.bytes}
+	}
+	return txs, nil
+}
+
+func (c *Client) Peers(_ context.Context) ([]*types.Peer, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.peers, nil
+}
+
+func (c *Client) Status(_ context.Context) (*types.SyncStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.syncStatus, nil
+}
+
+// PostTx adds the signed transaction to the mempool, it is executed by the next CommitBlock
+func (c *Client) PostTx(_ context.Context, txBytes []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	tx, err := decodeTx(txBytes, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := txHash(txBytes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.txs[hash]; ok {
+		return nil, nil, crgerrs.WrapError(crgerrs.ErrAlreadyExists, "tx already committed")
+	}
+	for _, p := range c.mempool {
+		if p.hash == hash {
+			return nil, nil, crgerrs.ErrTxInMempool
+		}
+	}
+	if expected := c.nextSequence(tx.From); tx.Sequence != expected {
+		return nil, nil, crgerrs.WrapError(crgerrs.ErrSequenceMismatch, fmt.Sprintf("expected sequence %d, got %d", expected, tx.Sequence))
+	}
+	c.mempool = append(c.mempool, &pendingTx{hash: hash, bytes: txBytes, tx: tx})
+	return &types.TransactionIdentifier{Hash: hash}, nil, nil
+}
+
+// Transfer signs the transfer of amount from the account to the address and adds it to the mempool,
+// it is a shortcut of the construction API for tests which only need transactions
+func (c *Client) Transfer(from *Account, to string, amount *big.Int) (*types.TransactionIdentifier, error) {
+	c.mu.RLock()
+	sequence := c.nextSequence(from.Address)
+	c.mu.RUnlock()
+	tx := &Tx{From: from.Address, To: to, Amount: amount.String(), Sequence: sequence}
+	tx.PubKey = from.PublicKey().Bytes
+	tx.Signature = from.sign(tx.signBytes())
+	id, _, err := c.PostTx(context.Background(), tx.bytes())
+	return id, err
+}
+
+// WaitTxInclusion blocks until the transaction is committed or ctx is done
+func (c *Client) WaitTxInclusion(ctx context.Context, hash string) (*crgtypes.TxInclusionResult, error) {
+	for {
+		c.mu.RLock()
+		tx, ok := c.txs[hash]
+		committed := c.committed
+		c.mu.RUnlock()
+
+		if ok {
+			result := &crgtypes.TxInclusionResult{Block: tx.block}
+			// failed transfers report the cosmos-sdk insufficient funds error
+			if tx.status == StatusFailure {
+				result.Code = 5
+				result.Codespace = crgerrs.SDKCodespace
+				result.Log = "insufficient funds"
+			}
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-committed:
+		}
+	}
+}
+
+// ConstructionMetadataFromOptions returns the next sequence of the sender
+func (c *Client) ConstructionMetadataFromOptions(_ context.Context, options map[string]interface{}) (map[string]interface{}, error) {
+	from, _ := options[OptionFrom].(string)
+	if err := validateAddress(from); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return map[string]interface{}{MetadataSequence: c.nextSequence(from)}, nil
+}
+
+func (c *Client) SupportedOperations() []string {
+	return []string{OpTransfer}
+}
+
+func (c *Client) OperationStatuses() []*types.OperationStatus {
+	return []*types.OperationStatus{
+		{Status: StatusSuccess, Successful: true},
+		{Status: StatusFailure, Successful: false},
+	}
+}
+
+func (c *Client) Version() string {
+	return c.config.Version
+}
+
+func (c *Client) OperationSchemas() []*crgtypes.OperationSchema {
+	return []*crgtypes.OperationSchema{{
+		Type:           OpTransfer,
+		RequireAccount: true,
+		Amount:         crgtypes.AmountAny,
+		Currencies:     []*types.Currency{c.config.Currency},
+		Balanced:       true,
+	}}
+}
+
+// SignedTx adds the signature of the sender to the unsigned transaction
+func (c *Client) SignedTx(_ context.Context, txBytes []byte, sigs []*types.Signature) ([]byte, error) {
+	tx, err := decodeTx(txBytes, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigs) != 1 || sigs[0] == nil || sigs[0].PublicKey == nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, "exactly one signature with its public key is required")
+	}
+	tx.PubKey = sigs[0].PublicKey.Bytes
+	tx.Signature = sigs[0].Bytes
+	if err := tx.verifySignature(); err != nil {
+		return nil, err
+	}
+	return tx.bytes(), nil
+}
+
+func (c *Client) TxOperationsAndSignersAccountIdentifiers(signed bool, txBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	tx, err := decodeTx(txBytes, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+	var signers []*types.AccountIdentifier
+	if signed {
+		signers = []*types.AccountIdentifier{{Address: tx.From}}
+	}
+	return tx.operations(c.config.Currency, ""), signers, nil
+}
+
+// ConstructionPayload builds the unsigned transfer, the sender sequence is read from the metadata
+func (c *Client) ConstructionPayload(_ context.Context, req *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, error) {
+	tx, err := c.txFromOperations(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	tx.Sequence, err = sequenceFromMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ConstructionPayloadsResponse{
+		UnsignedTransaction: fmt.Sprintf("%x", tx.bytes()),
+		Payloads: []*types.SigningPayload{{
+			AccountIdentifier: &types.AccountIdentifier{Address: tx.From},
+			Bytes:             tx.signBytes(),
+			SignatureType:     types.Ed25519,
+		}},
+	}, nil
+}
+
+func (c *Client) PreprocessOperationsToOptions(_ context.Context, req *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, error) {
+	tx, err := c.txFromOperations(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ConstructionPreprocessResponse{
+		Options:            map[string]interface{}{OptionFrom: tx.From},
+		RequiredPublicKeys: []*types.AccountIdentifier{{Address: tx.From}},
+	}, nil
+}
+
+func (c *Client) AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	if pubKey == nil || pubKey.CurveType != types.Edwards25519 {
+		return nil, crgerrs.ErrUnsupportedCurve
+	}
+	if len(pubKey.Bytes) != 32 {
+		return nil, crgerrs.WrapError(crgerrs.ErrInvalidPubkey, "invalid public key length")
+	}
+	return &types.AccountIdentifier{Address: addressFromPubKey(pubKey.Bytes)}, nil
+}
+
+// txFromOperations builds the transfer given the operations, checking their currency
+func (c *Client) txFromOperations(ops []*types.Operation) (*Tx, error) {
+	for _, op := range ops {
+		if op.Amount != nil && (op.Amount.Currency == nil ||
+			op.Amount.Currency.Symbol != c.config.Currency.Symbol ||
+			op.Amount.Currency.Decimals != c.config.Currency.Decimals) {
+			return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "unsupported currency")
+		}
+	}
+	return txFromOperations(ops)
+}
+
+// sequenceFromMetadata reads the sequence from the construction metadata,
+// numbers decoded from JSON are float64
+func sequenceFromMetadata(meta map[string]interface{}) (uint64, error) {
+	switch v := meta[MetadataSequence].(type) {
+	case uint64:
+		return v, nil
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			break
+		}
+		return uint64(v), nil
+	case nil:
+		return 0, crgerrs.WrapError(crgerrs.ErrBadArgument, "missing "+MetadataSequence+" metadata")
+	}
+	return 0, crgerrs.WrapError(crgerrs.ErrBadArgument, "invalid "+MetadataSequence+" metadata")
+}
@@ -0,0 +1,42 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package clienttest
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+)
+
+// NewHTTPServer serves the rosetta API backed by client, in online or offline mode,
+// the caller must close the returned server
+func NewHTTPServer(client *Client, offline bool) (*httptest.Server, error) {
+	srv, err := server.NewServer(server.Settings{
+		Network:   client.Network(),
+		Client:    client,
+		Offline:   offline,
+		Retries:   1,
+		RetryWait: time.Millisecond,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(srv.Handler()), nil
+}
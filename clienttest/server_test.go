@@ -0,0 +1,160 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package clienttest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+)
+
+func TestTransferOverHTTP(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	client.CommitBlock()
+
+	online, err := clienttest.NewHTTPServer(client, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer online.Close()
+	offline, err := clienttest.NewHTTPServer(client, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer offline.Close()
+	network := client.Network()
+
+	// the sender is derived from its public key offline
+	derive := new(types.ConstructionDeriveResponse)
+	post(t, offline.URL, "/construction/derive", &types.ConstructionDeriveRequest{NetworkIdentifier: network, PublicKey: alice.PublicKey()}, derive)
+	if derive.AccountIdentifier.Address != alice.Address {
+		t.Fatalf("expected derived address %s, got %s", alice.Address, derive.AccountIdentifier.Address)
+	}
+
+	ops := []*types.Operation{
+		{OperationIdentifier: &types.OperationIdentifier{Index: 0}, Type: clienttest.OpTransfer, Account: derive.AccountIdentifier, Amount: &types.Amount{Value: "-30", Currency: client.Currency()}},
+		{OperationIdentifier: &types.OperationIdentifier{Index: 1}, Type: clienttest.OpTransfer, Account: bob.Identifier(), Amount: &types.Amount{Value: "30", Currency: client.Currency()}},
+	}
+	preprocess := new(types.ConstructionPreprocessResponse)
+	post(t, offline.URL, "/construction/preprocess", &types.ConstructionPreprocessRequest{NetworkIdentifier: network, Operations: ops}, preprocess)
+
+	metadata := new(types.ConstructionMetadataResponse)
+	post(t, online.URL, "/construction/metadata", &types.ConstructionMetadataRequest{
+		NetworkIdentifier: network,
+		Options:           preprocess.Options,
+		PublicKeys:        []*types.PublicKey{alice.PublicKey()},
+	}, metadata)
+
+	payloads := new(types.ConstructionPayloadsResponse)
+	post(t, offline.URL, "/construction/payloads", &types.ConstructionPayloadsRequest{NetworkIdentifier: network, Operations: ops, Metadata: metadata.Metadata}, payloads)
+	if len(payloads.Payloads) != 1 {
+		t.Fatalf("expected one payload to sign, got %d", len(payloads.Payloads))
+	}
+
+	sig, err := alice.Sign(payloads.Payloads[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	combine := new(types.ConstructionCombineResponse)
+	post(t, offline.URL, "/construction/combine", &types.ConstructionCombineRequest{
+		NetworkIdentifier:   network,
+		UnsignedTransaction: payloads.UnsignedTransaction,
+		Signatures:          []*types.Signature{sig},
+	}, combine)
+
+	parse := new(types.ConstructionParseResponse)
+	post(t, offline.URL, "/construction/parse", &types.ConstructionParseRequest{NetworkIdentifier: network, Signed: true, Transaction: combine.SignedTransaction}, parse)
+	if len(parse.Operations) != 2 || len(parse.AccountIdentifierSigners) != 1 || parse.AccountIdentifierSigners[0].Address != alice.Address {
+		t.Fatalf("unexpected parsed transaction %+v", parse)
+	}
+
+	hash := new(types.TransactionIdentifierResponse)
+	post(t, offline.URL, "/construction/hash", &types.ConstructionHashRequest{NetworkIdentifier: network, SignedTransaction: combine.SignedTransaction}, hash)
+
+	// offline servers cannot submit
+	if status := postStatus(t, offline.URL, "/construction/submit", &types.ConstructionSubmitRequest{NetworkIdentifier: network, SignedTransaction: combine.SignedTransaction}, nil); status == http.StatusOK {
+		t.Fatal("expected the offline server to reject the submission")
+	}
+	submit := new(types.TransactionIdentifierResponse)
+	post(t, online.URL, "/construction/submit", &types.ConstructionSubmitRequest{NetworkIdentifier: network, SignedTransaction: combine.SignedTransaction}, submit)
+	if submit.TransactionIdentifier.Hash != hash.TransactionIdentifier.Hash {
+		t.Fatalf("submitted hash %s differs from /construction/hash %s", submit.TransactionIdentifier.Hash, hash.TransactionIdentifier.Hash)
+	}
+
+	block := client.CommitBlock()
+	tx := new(types.BlockTransactionResponse)
+	post(t, online.URL, "/block/transaction", &types.BlockTransactionRequest{
+		NetworkIdentifier:     network,
+		BlockIdentifier:       block,
+		TransactionIdentifier: submit.TransactionIdentifier,
+	}, tx)
+	if len(tx.Transaction.Operations) != 2 || *tx.Transaction.Operations[0].Status != clienttest.StatusSuccess {
+		t.Fatalf("unexpected committed transaction %+v", tx.Transaction)
+	}
+
+	for account, want := range map[*clienttest.Account]string{alice: "70", bob: "30"} {
+		balance := new(types.AccountBalanceResponse)
+		post(t, online.URL, "/account/balance", &types.AccountBalanceRequest{NetworkIdentifier: network, AccountIdentifier: account.Identifier()}, balance)
+		if got := balance.Balances[0].Value; got != want {
+			t.Errorf("expected balance %s of %s, got %s", want, account.Address, got)
+		}
+	}
+}
+
+// post posts the request to the server and decodes the response into resp, failing the test on errors
+func post(t *testing.T, url, path string, req, resp interface{}) {
+	t.Helper()
+	if status := postStatus(t, url, path, req, resp); status != http.StatusOK {
+		t.Fatalf("%s failed with status %d", path, status)
+	}
+}
+
+// postStatus posts the request to the server, decodes the successful responses into resp if not nil
+// and returns the HTTP status
+func postStatus(t *testing.T, url, path string, req, resp interface{}) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpResp, err := http.Post(url+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		rosErr := new(types.Error)
+		_ = json.NewDecoder(httpResp.Body).Decode(rosErr)
+		t.Logf("%s: error %d: %s %v", path, rosErr.Code, rosErr.Message, rosErr.Details)
+		return httpResp.StatusCode
+	}
+	if resp != nil {
+		if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+			t.Fatalf("cannot decode %s response: %v", path, err)
+		}
+	}
+	return httpResp.StatusCode
+}
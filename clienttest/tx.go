@@ -0,0 +1,185 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package clienttest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// OpTransfer is the only operation type supported by the in-memory chain
+const OpTransfer = "transfer"
+
+const (
+	// StatusSuccess is the status of the operations of executed transactions
+	StatusSuccess = "success"
+	// StatusFailure is the status of the operations of transactions which failed execution
+	StatusFailure = "failure"
+)
+
+// Tx is the transaction format of the in-memory chain: a transfer of Amount
+// of the chain denom from From to To, signed by the key of From.
+// Transactions are encoded as JSON.
+type Tx struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   string `json:"amount"`
+	Sequence uint64 `json:"sequence"`
+	// PubKey and Signature are empty in unsigned transactions
+	PubKey    []byte `json:"pub_key,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// decodeTx decodes the transaction bytes, checking signed transactions are correctly signed
+func decodeTx(txBytes []byte, signed bool) (*Tx, error) {
+	tx := new(Tx)
+	if err := json.Unmarshal(txBytes, tx); err != nil {
+		return nil, crgerrs.Wrap(crgerrs.WrapError(crgerrs.ErrCodec, "cannot decode tx"), err)
+	}
+	if err := tx.validateBasic(); err != nil {
+		return nil, err
+	}
+	if signed {
+		if err := tx.verifySignature(); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+func (tx *Tx) validateBasic() error {
+	if err := validateAddress(tx.From); err != nil {
+		return err
+	}
+	if err := validateAddress(tx.To); err != nil {
+		return err
+	}
+	amount, ok := new(big.Int).SetString(tx.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return crgerrs.WrapError(crgerrs.ErrInvalidTransaction, fmt.Sprintf("invalid amount %q", tx.Amount))
+	}
+	return nil
+}
+
+func (tx *Tx) amount() *big.Int {
+	amount, _ := new(big.Int).SetString(tx.Amount, 10)
+	return amount
+}
+
+// signBytes returns the bytes signed by the sender
+func (tx *Tx) signBytes() []byte {
+	unsigned := *tx
+	unsigned.PubKey, unsigned.Signature = nil, nil
+	return unsigned.bytes()
+}
+
+func (tx *Tx) bytes() []byte {
+	b, err := json.Marshal(tx)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (tx *Tx) verifySignature() error {
+	if len(tx.PubKey) != ed25519.PublicKeySize {
+		return crgerrs.WrapError(crgerrs.ErrInvalidPubkey, "invalid public key length")
+	}
+	if addressFromPubKey(tx.PubKey) != tx.From {
+		return crgerrs.WrapError(crgerrs.ErrUnauthorized, "public key does not match the sender")
+	}
+	if !ed25519.Verify(tx.PubKey, tx.signBytes(), tx.Signature) {
+		return crgerrs.WrapError(crgerrs.ErrUnauthorized, "invalid signature")
+	}
+	return nil
+}
+
+// hash returns the transaction hash, it matches the default /construction/hash one
+func txHash(txBytes []byte) string {
+	hash := sha256.Sum256(txBytes)
+	return crgtypes.FormatTxHash(crgtypes.TxHashFormatUpperHex, hash[:])
+}
+
+// operations returns the transfer operations of the transaction, status is empty for
+// transactions which were not executed
+func (tx *Tx) operations(currency *types.Currency, status string) []*types.Operation {
+	var s *string
+	if status != "" {
+		s = &status
+	}
+	return []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                OpTransfer,
+			Status:              s,
+			Account:             &types.AccountIdentifier{Address: tx.From},
+			Amount:              &types.Amount{Value: "-" + tx.Amount, Currency: currency},
+		},
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 1},
+			RelatedOperations:   []*types.OperationIdentifier{{Index: 0}},
+			Type:                OpTransfer,
+			Status:              s,
+			Account:             &types.AccountIdentifier{Address: tx.To},
+			Amount:              &types.Amount{Value: tx.Amount, Currency: currency},
+		},
+	}
+}
+
+// txFromOperations builds the unsigned transfer given the operations, which must be
+// a debit of the sender and a credit of the same amount to the receiver
+func txFromOperations(ops []*types.Operation) (*Tx, error) {
+	if len(ops) != 2 {
+		return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "a transfer is made of two operations")
+	}
+	var from, to *types.Operation
+	for _, op := range ops {
+		if op.Type != OpTransfer || op.Account == nil || op.Amount == nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "invalid transfer operation")
+		}
+		value, ok := new(big.Int).SetString(op.Amount.Value, 10)
+		if !ok {
+			return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "invalid amount "+op.Amount.Value)
+		}
+		if value.Sign() < 0 {
+			from = op
+		} else {
+			to = op
+		}
+	}
+	if from == nil || to == nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "a transfer needs a debit and a credit")
+	}
+	amount := to.Amount.Value
+	if from.Amount.Value != "-"+amount {
+		return nil, crgerrs.WrapError(crgerrs.ErrInvalidOperation, "debit and credit amounts differ")
+	}
+	tx := &Tx{From: from.Account.Address, To: to.Account.Address, Amount: amount}
+	if err := tx.validateBasic(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,47 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package main
+
+// Client implementations are compiled in by importing their packages here,
+// each package registers its client factories with server.RegisterClient in its init function:
+//
+//	import _ "github.com/example/cosmos-client/rosetta"
+//
+// Builds serving other clients can add a file importing them, guarded by a build tag
+// such as "//go:build mychain" so that the client is compiled in with go build -tags mychain.
+
+import (
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// clienttestClient is the name of the in-memory clienttest chain, it is always compiled in
+// and allows to try the binary and a configuration without a node
+const clienttestClient = "clienttest"
+
+func init() {
+	server.RegisterClient(server.ClientFactory{
+		Name:      clienttestClient,
+		NewConfig: func() interface{} { return new(clienttest.Config) },
+		New: func(config interface{}) (crgtypes.Client, error) {
+			return clienttest.NewClient(*config.(*clienttest.Config)), nil
+		},
+	})
+}
@@ -0,0 +1,70 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+)
+
+// errorsCmd exports the error catalog of a compiled in client, which contains the library
+// errors and the ones registered by the client. Without client the default registry is exported.
+func errorsCmd(args []string) error {
+	fs := flag.NewFlagSet("errors", flag.ContinueOnError)
+	format := fs.String("format", string(crgerrs.CatalogJSON), fmt.Sprintf("output format, one of %v", crgerrs.CatalogFormats))
+	against := fs.String("against", "", "JSON catalog of a previous release, fails if an error code was reused")
+	client := fs.String("client", "", fmt.Sprintf("name of the client whose errors are exported, one of %v", server.Clients()))
+	sdkVersion := fs.String("client-sdk-version", "", "cosmos-sdk version selecting the client factory, required if the client supports multiple versions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	registry := crgerrs.DefaultRegistry()
+	if *client != "" {
+		var err error
+		if registry, err = server.ClientErrorRegistry(*client, *sdkVersion); err != nil {
+			return err
+		}
+	}
+	if err := registry.Seal(); err != nil {
+		return err
+	}
+	catalog := registry.Catalog()
+
+	if *against != "" {
+		f, err := os.Open(*against)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		previous, err := crgerrs.ReadCatalog(f)
+		if err != nil {
+			return err
+		}
+		if reused := crgerrs.ReusedCodes(previous, catalog); len(reused) != 0 {
+			return fmt.Errorf("error codes reused with a different message: %v", reused)
+		}
+	}
+
+	return registry.WriteCatalog(os.Stdout, crgerrs.CatalogFormat(*format))
+}
@@ -0,0 +1,57 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Command crg is the cosmos rosetta gateway command line interface
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: crg <command> [flags]
+
+commands:
+  start     start the gateway
+  errors    export the error catalog
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		_, _ = fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "start":
+		err = startCmd(os.Args[2:])
+	case "errors":
+		err = errorsCmd(os.Args[2:])
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
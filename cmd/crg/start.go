@@ -0,0 +1,84 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tendermint/cosmos-rosetta-gateway/config"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+)
+
+// startCmd loads the configuration and starts the gateway
+func startCmd(args []string) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	loader := config.NewLoader(fs)
+	watchInterval := fs.Duration("watch-interval", config.DefaultWatchInterval, "interval at which the configuration file is checked for changes, the configuration is also reloaded on SIGHUP")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.Settings()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// interrupting the gateway while it waits for the node aborts the startup
+	startCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	srv, err := server.NewServerContext(startCtx, settings)
+	stop()
+	if err != nil {
+		return err
+	}
+	go config.Watch(ctx, loader.File(), *watchInterval, func() { reload(loader, srv) })
+
+	logging.Infof("starting rosetta gateway for %s/%s with client %s on %s", cfg.Blockchain, cfg.Network, cfg.Client, cfg.Listen)
+	return srv.Start()
+}
+
+// reload loads the configuration again and applies it to the running server,
+// invalid configurations and changes to settings which cannot be reloaded are rejected
+func reload(loader *config.Loader, srv server.Server) {
+	cfg, err := loader.Load()
+	if err != nil {
+		logging.Errorf("configuration reload rejected, invalid configuration: %s", err)
+		return
+	}
+	settings, err := cfg.Settings()
+	if err != nil {
+		logging.Errorf("configuration reload rejected, invalid configuration: %s", err)
+		return
+	}
+	if err := srv.Reload(settings); err != nil {
+		logging.Errorf("configuration reload rejected: %s", err)
+		return
+	}
+	logging.Infof("configuration reloaded")
+}
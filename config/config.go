@@ -0,0 +1,307 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package config defines the configuration of the standalone rosetta gateway,
+// which can be loaded from YAML or TOML files, environment variables and flags.
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+)
+
+// Config defines the gateway configuration
+type Config struct {
+	// Client is the name of the compiled in client implementation to use
+	Client string `yaml:"client" toml:"client"`
+	// ClientSDKVersion is the cosmos-sdk version of the node, required when the client
+	// supports multiple versions
+	ClientSDKVersion string `yaml:"client_sdk_version" toml:"client_sdk_version"`
+	// ClientConfig is the configuration decoded into the client factory configuration
+	ClientConfig map[string]interface{} `yaml:"client_config" toml:"client_config"`
+	// Listen is the address the gateway listens at
+	Listen string `yaml:"listen" toml:"listen"`
+	// Blockchain and Network identify the network exposed by the gateway
+	Blockchain string `yaml:"blockchain" toml:"blockchain"`
+	Network    string `yaml:"network" toml:"network"`
+	// Offline exposes only the offline construction endpoints
+	Offline bool `yaml:"offline" toml:"offline"`
+	// Retries is the number of node readiness checks attempted at startup
+	Retries int `yaml:"retries" toml:"retries"`
+	// RetryWait is the time waited between readiness checks
+	RetryWait Duration `yaml:"retry_wait" toml:"retry_wait"`
+	// GasPrices are the gas prices used to suggest fees, such as "0.025uatom,0.1stake"
+	GasPrices string `yaml:"gas_prices" toml:"gas_prices"`
+	// GasAdjustment multiplies the simulated gas to obtain the gas limit, zero means the library default
+	GasAdjustment float64 `yaml:"gas_adjustment" toml:"gas_adjustment"`
+	// VerifyRoundTrip enables the construction round trip consistency check
+	VerifyRoundTrip bool `yaml:"verify_round_trip" toml:"verify_round_trip"`
+	// HTTPStatusFromErrors makes error responses use the HTTP status of the error
+	HTTPStatusFromErrors bool `yaml:"http_status_from_errors" toml:"http_status_from_errors"`
+	// Debug includes the errors cause chain in the responses
+	Debug bool `yaml:"debug" toml:"debug"`
+	// TLS configures HTTPS
+	TLS TLS `yaml:"tls" toml:"tls"`
+	// Limits configures the HTTP server limits
+	Limits Limits `yaml:"limits" toml:"limits"`
+	// Admin configures the admin API
+	Admin Admin `yaml:"admin" toml:"admin"`
+	// Timeouts configures the rosetta endpoints timeouts
+	Timeouts Timeouts `yaml:"timeouts" toml:"timeouts"`
+	// InlineTransactionsLimit is the maximum number of transactions included in /block responses,
+	// zero means no limit
+	InlineTransactionsLimit int `yaml:"inline_transactions_limit" toml:"inline_transactions_limit"`
+	// LogLevel is the minimum level of the logged messages among debug, info, warn and error
+	LogLevel string `yaml:"log_level" toml:"log_level"`
+}
+
+// TLS defines the certificate and key used to serve HTTPS
+type TLS struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+}
+
+// Admin defines the admin API listener, the admin API is disabled if Listen is empty
+type Admin struct {
+	Listen string `yaml:"listen" toml:"listen"`
+	Token  string `yaml:"token" toml:"token"`
+}
+
+// Timeouts defines the time the rosetta endpoints have to complete, zero values mean no timeout.
+// Endpoints overrides Default per endpoint path, such as "/block".
+type Timeouts struct {
+	Default   Duration            `yaml:"default" toml:"default"`
+	Endpoints map[string]Duration `yaml:"endpoints" toml:"endpoints"`
+}
+
+// Limits defines the HTTP server limits, zero values mean no limit
+type Limits struct {
+	ReadTimeout         Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout        Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout         Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxHeaderBytes      int      `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	MaxRequestBodyBytes int64    `yaml:"max_request_body_bytes" toml:"max_request_body_bytes"`
+	// RequestsPerSecond is the rate limit of the rosetta API across all clients,
+	// RequestsBurst defaults to RequestsPerSecond rounded up
+	RequestsPerSecond float64 `yaml:"requests_per_second" toml:"requests_per_second"`
+	RequestsBurst     int     `yaml:"requests_burst" toml:"requests_burst"`
+}
+
+// Duration is a time.Duration expressed as a string such as "5s" in configuration files
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the default configuration
+func Default() Config {
+	return Config{
+		Listen:    "0.0.0.0:8080",
+		Retries:   server.DefaultRetries,
+		RetryWait: Duration(server.DefaultRetryWait),
+		Limits: Limits{
+			ReadTimeout:         Duration(30 * time.Second),
+			WriteTimeout:        Duration(2 * time.Minute),
+			IdleTimeout:         Duration(2 * time.Minute),
+			MaxRequestBodyBytes: 10 << 20,
+		},
+	}
+}
+
+// Validate checks the configuration is valid
+func (c Config) Validate() error {
+	if c.Client == "" {
+		return fmt.Errorf("client is required, available clients: %v", server.Clients())
+	}
+	if !knownClient(c.Client) {
+		return fmt.Errorf("unknown client %q, available clients: %v; client implementations must be compiled in the binary, see cmd/crg/clients.go", c.Client, server.Clients())
+	}
+	if c.Blockchain == "" || c.Network == "" {
+		return fmt.Errorf("blockchain and network are required")
+	}
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", c.Listen, err)
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	if c.RetryWait < 0 {
+		return fmt.Errorf("retry wait must not be negative")
+	}
+	if _, err := ParseGasPrices(c.GasPrices); err != nil {
+		return err
+	}
+	if c.GasAdjustment < 0 {
+		return fmt.Errorf("gas adjustment must not be negative")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls cert file and key file must be provided together")
+	}
+	for _, f := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			return fmt.Errorf("invalid tls file: %w", err)
+		}
+	}
+	if c.Limits.ReadTimeout < 0 || c.Limits.WriteTimeout < 0 || c.Limits.IdleTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if c.Limits.MaxHeaderBytes < 0 || c.Limits.MaxRequestBodyBytes < 0 {
+		return fmt.Errorf("size limits must not be negative")
+	}
+	if c.Admin.Listen != "" && c.Admin.Token == "" {
+		return fmt.Errorf("admin token is required when the admin API is enabled")
+	}
+	if c.Admin.Listen != "" && c.Admin.Listen == c.Listen {
+		return fmt.Errorf("admin API must listen on a different address than the rosetta API")
+	}
+	if c.InlineTransactionsLimit < 0 {
+		return fmt.Errorf("inline transactions limit must not be negative")
+	}
+	if c.Timeouts.Default < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if c.Limits.RequestsPerSecond < 0 || c.Limits.RequestsBurst < 0 {
+		return fmt.Errorf("rate limits must not be negative")
+	}
+	for endpoint, timeout := range c.Timeouts.Endpoints {
+		if timeout < 0 {
+			return fmt.Errorf("timeout of %s must not be negative", endpoint)
+		}
+	}
+	return nil
+}
+
+// knownClient reports if a client implementation is registered with the given name
+func knownClient(name string) bool {
+	for _, client := range server.Clients() {
+		if client == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings builds the server settings, the client is built by the server
+// from the registered client factories
+func (c Config) Settings() (server.Settings, error) {
+	if err := c.Validate(); err != nil {
+		return server.Settings{}, err
+	}
+	gasPrices, err := ParseGasPrices(c.GasPrices)
+	if err != nil {
+		return server.Settings{}, err
+	}
+	return server.Settings{
+		Network: &types.NetworkIdentifier{
+			Blockchain: c.Blockchain,
+			Network:    c.Network,
+		},
+		ClientName:              c.Client,
+		ClientSDKVersion:        c.ClientSDKVersion,
+		ClientConfig:            c.ClientConfig,
+		Listen:                  c.Listen,
+		Offline:                 c.Offline,
+		Retries:                 c.Retries,
+		RetryWait:               time.Duration(c.RetryWait),
+		GasPrices:               gasPrices,
+		GasAdjustment:           c.GasAdjustment,
+		VerifyRoundTrip:         c.VerifyRoundTrip,
+		Debug:                   c.Debug,
+		HTTPStatusFromErrors:    c.HTTPStatusFromErrors,
+		TLSCertFile:             c.TLS.CertFile,
+		TLSKeyFile:              c.TLS.KeyFile,
+		ReadTimeout:             time.Duration(c.Limits.ReadTimeout),
+		WriteTimeout:            time.Duration(c.Limits.WriteTimeout),
+		IdleTimeout:             time.Duration(c.Limits.IdleTimeout),
+		MaxHeaderBytes:          c.Limits.MaxHeaderBytes,
+		MaxRequestBodyBytes:     c.Limits.MaxRequestBodyBytes,
+		AdminListen:             c.Admin.Listen,
+		AdminToken:              c.Admin.Token,
+		EndpointTimeout:         time.Duration(c.Timeouts.Default),
+		EndpointTimeouts:        endpointTimeouts(c.Timeouts.Endpoints),
+		InlineTransactionsLimit: c.InlineTransactionsLimit,
+		RateLimit:               c.Limits.RequestsPerSecond,
+		RateLimitBurst:          c.Limits.RequestsBurst,
+		LogLevel:                c.LogLevel,
+	}, nil
+}
+
+// endpointTimeouts converts the configured endpoint timeouts
+func endpointTimeouts(m map[string]Duration) map[string]time.Duration {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]time.Duration, len(m))
+	for endpoint, timeout := range m {
+		out[endpoint] = time.Duration(timeout)
+	}
+	return out
+}
+
+// gasPriceRegex matches a decimal amount followed by a denom
+var gasPriceRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+
+// ParseGasPrices parses comma separated gas prices, such as "0.025uatom,0.1stake"
+func ParseGasPrices(s string) ([]*types.Amount, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var prices []*types.Amount
+	for _, price := range strings.Split(s, ",") {
+		price = strings.TrimSpace(price)
+		match := gasPriceRegex.FindStringSubmatch(price)
+		if match == nil {
+			return nil, fmt.Errorf("invalid gas price %q", price)
+		}
+		if _, ok := new(big.Rat).SetString(match[1]); !ok {
+			return nil, fmt.Errorf("invalid gas price amount %q", match[1])
+		}
+		prices = append(prices, &types.Amount{
+			Value:    match[1],
+			Currency: &types.Currency{Symbol: match[2]},
+		})
+	}
+	return prices, nil
+}
@@ -0,0 +1,226 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the configuration
+const EnvPrefix = "CRG_"
+
+// option describes a configuration value settable through environment variables and flags
+type option struct {
+	name    string
+	usage   string
+	boolean bool
+	set     func(c *Config, v string) error
+}
+
+// options lists the values which can be set through environment variables and flags,
+// the environment variable name is the upper case option name with dots and dashes
+// replaced by underscores and prefixed by EnvPrefix, such as CRG_TLS_CERT_FILE
+var options = []option{
+	stringOption("client", "name of the compiled in client implementation", func(c *Config) *string { return &c.Client }),
+	stringOption("client-sdk-version", "cosmos-sdk version of the node, selects the client implementation", func(c *Config) *string { return &c.ClientSDKVersion }),
+	stringOption("listen", "address the gateway listens at", func(c *Config) *string { return &c.Listen }),
+	stringOption("blockchain", "blockchain name", func(c *Config) *string { return &c.Blockchain }),
+	stringOption("network", "network name", func(c *Config) *string { return &c.Network }),
+	boolOption("offline", "expose only the offline endpoints", func(c *Config) *bool { return &c.Offline }),
+	intOption("retries", "node readiness checks attempted at startup", func(c *Config) *int { return &c.Retries }),
+	durationOption("retry-wait", "time waited between readiness checks", func(c *Config) *Duration { return &c.RetryWait }),
+	stringOption("gas-prices", "gas prices used to suggest fees, such as 0.025uatom", func(c *Config) *string { return &c.GasPrices }),
+	{name: "gas-adjustment", usage: "factor applied to the simulated gas", set: func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		c.GasAdjustment = f
+		return err
+	}},
+	boolOption("verify-round-trip", "check the construction round trip consistency", func(c *Config) *bool { return &c.VerifyRoundTrip }),
+	boolOption("http-status-from-errors", "use the HTTP status of the errors in responses", func(c *Config) *bool { return &c.HTTPStatusFromErrors }),
+	boolOption("debug", "include the errors cause chain in responses", func(c *Config) *bool { return &c.Debug }),
+	stringOption("log-level", "minimum level of the logged messages: debug, info, warn or error", func(c *Config) *string { return &c.LogLevel }),
+	stringOption("tls.cert-file", "TLS certificate file", func(c *Config) *string { return &c.TLS.CertFile }),
+	stringOption("tls.key-file", "TLS key file", func(c *Config) *string { return &c.TLS.KeyFile }),
+	durationOption("limits.read-timeout", "HTTP read timeout", func(c *Config) *Duration { return &c.Limits.ReadTimeout }),
+	durationOption("limits.write-timeout", "HTTP write timeout", func(c *Config) *Duration { return &c.Limits.WriteTimeout }),
+	durationOption("limits.idle-timeout", "HTTP idle timeout", func(c *Config) *Duration { return &c.Limits.IdleTimeout }),
+	intOption("limits.max-header-bytes", "maximum size of the request headers", func(c *Config) *int { return &c.Limits.MaxHeaderBytes }),
+	{name: "limits.max-request-body-bytes", usage: "maximum size of the request body", set: func(c *Config, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		c.Limits.MaxRequestBodyBytes = n
+		return err
+	}},
+	{name: "limits.requests-per-second", usage: "rate limit of the rosetta API, zero means no limit", set: func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		c.Limits.RequestsPerSecond = f
+		return err
+	}},
+	intOption("limits.requests-burst", "requests which can exceed the rate limit at once", func(c *Config) *int { return &c.Limits.RequestsBurst }),
+	stringOption("admin.listen", "admin API listen address, empty disables the admin API", func(c *Config) *string { return &c.Admin.Listen }),
+	stringOption("admin.token", "admin API bearer token", func(c *Config) *string { return &c.Admin.Token }),
+	intOption("inline-transactions-limit", "maximum number of transactions included in /block responses, zero means no limit", func(c *Config) *int { return &c.InlineTransactionsLimit }),
+	durationOption("timeouts.default", "time the rosetta endpoints have to complete, zero means no timeout", func(c *Config) *Duration { return &c.Timeouts.Default }),
+}
+
+func stringOption(name, usage string, field func(c *Config) *string) option {
+	return option{name: name, usage: usage, set: func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}}
+}
+
+func boolOption(name, usage string, field func(c *Config) *bool) option {
+	return option{name: name, usage: usage, boolean: true, set: func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		*field(c) = b
+		return err
+	}}
+}
+
+func intOption(name, usage string, field func(c *Config) *int) option {
+	return option{name: name, usage: usage, set: func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		*field(c) = n
+		return err
+	}}
+}
+
+func durationOption(name, usage string, field func(c *Config) *Duration) option {
+	return option{name: name, usage: usage, set: func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		*field(c) = Duration(d)
+		return err
+	}}
+}
+
+// flagValue stores the raw value of an option flag
+type flagValue struct {
+	value   string
+	boolean bool
+}
+
+func (f *flagValue) String() string     { return f.value }
+func (f *flagValue) Set(v string) error { f.value = v; return nil }
+func (f *flagValue) IsBoolFlag() bool   { return f.boolean }
+
+// envName returns the environment variable name of the option
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// Loader loads the configuration with the following precedence, from lowest to highest:
+// defaults, configuration file, environment variables, flags.
+type Loader struct {
+	fs    *flag.FlagSet
+	file  *string
+	flags map[string]*flagValue
+}
+
+// NewLoader registers the configuration flags in fs
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{
+		fs:    fs,
+		file:  fs.String("config", "", "configuration file, YAML or TOML, env "+EnvPrefix+"CONFIG"),
+		flags: make(map[string]*flagValue, len(options)),
+	}
+	for _, opt := range options {
+		v := &flagValue{boolean: opt.boolean}
+		fs.Var(v, opt.name, fmt.Sprintf("%s, env %s", opt.usage, envName(opt.name)))
+		l.flags[opt.name] = v
+	}
+	return l
+}
+
+// File returns the configuration file path, if any
+func (l *Loader) File() string {
+	if *l.file != "" {
+		return *l.file
+	}
+	return os.Getenv(EnvPrefix + "CONFIG")
+}
+
+// Load loads and validates the configuration, the flag set must be already parsed
+func (l *Loader) Load() (Config, error) {
+	cfg := Default()
+	if path := l.File(); path != "" {
+		if err := LoadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, opt := range options {
+		v, ok := os.LookupEnv(envName(opt.name))
+		if !ok {
+			continue
+		}
+		if err := opt.set(&cfg, v); err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", envName(opt.name), err)
+		}
+	}
+
+	var err error
+	l.fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, opt := range options {
+			if opt.name != f.Name {
+				continue
+			}
+			if setErr := opt.set(&cfg, l.flags[opt.name].value); setErr != nil {
+				err = fmt.Errorf("invalid flag -%s: %w", opt.name, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// LoadFile decodes the configuration file into cfg, the format is inferred from the extension
+func LoadFile(path string, cfg *Config) error {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bz, cfg)
+	case ".toml":
+		err = toml.Unmarshal(bz, cfg)
+	default:
+		return fmt.Errorf("unsupported configuration file format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+func init() {
+	server.RegisterClient(server.ClientFactory{
+		Name: "config-test",
+		New:  func(interface{}) (crgtypes.Client, error) { return nil, nil },
+	})
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "client: config-test\nblockchain: file\nnetwork: file\nretries: 7\nlisten: 127.0.0.1:1000\ntimeouts:\n  default: 10s\n",
+		"config.toml": "client = \"config-test\"\nblockchain = \"file\"\nnetwork = \"file\"\nretries = 7\nlisten = \"127.0.0.1:1000\"\n[timeouts]\ndefault = \"10s\"\n",
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		flags []string
+		want  func(c Config) bool
+	}{
+		{
+			name: "file overrides defaults",
+			want: func(c Config) bool {
+				return c.Blockchain == "file" && c.Retries == 7 && c.Listen == "127.0.0.1:1000" &&
+					c.Timeouts.Default == Duration(10*time.Second) && c.RetryWait == Duration(server.DefaultRetryWait)
+			},
+		},
+		{
+			name: "env overrides file",
+			env:  map[string]string{"CRG_NETWORK": "env", "CRG_RETRIES": "3", "CRG_TIMEOUTS_DEFAULT": "20s"},
+			want: func(c Config) bool {
+				return c.Blockchain == "file" && c.Network == "env" && c.Retries == 3 && c.Timeouts.Default == Duration(20*time.Second)
+			},
+		},
+		{
+			name:  "flags override env",
+			env:   map[string]string{"CRG_NETWORK": "env", "CRG_RETRIES": "3", "CRG_LOG_LEVEL": "warn"},
+			flags: []string{"-network", "flag", "-offline", "-log-level", "debug"},
+			want: func(c Config) bool {
+				return c.Network == "flag" && c.Retries == 3 && c.Offline && c.LogLevel == "debug"
+			},
+		},
+	}
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(file+"/"+tt.name, func(t *testing.T) {
+				for k, v := range tt.env {
+					setEnv(t, k, v)
+				}
+				fs := flag.NewFlagSet("test", flag.ContinueOnError)
+				loader := NewLoader(fs)
+				if err := fs.Parse(append([]string{"-config", path}, tt.flags...)); err != nil {
+					t.Fatal(err)
+				}
+				cfg, err := loader.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !tt.want(cfg) {
+					t.Fatalf("unexpected configuration %+v", cfg)
+				}
+			})
+		}
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	dir := t.TempDir()
+	ini := filepath.Join(dir, "config.ini")
+	if err := ioutil.WriteFile(ini, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		flags []string
+	}{
+		{name: "unsupported file format", flags: []string{"-config", ini}},
+		{name: "missing file", flags: []string{"-config", filepath.Join(dir, "missing.yaml")}},
+		{name: "invalid env", env: map[string]string{"CRG_RETRIES": "many"}},
+		{name: "invalid flag", flags: []string{"-retry-wait", "soon"}},
+		{name: "unknown client", flags: []string{"-client", "unknown"}},
+		{name: "missing network", flags: []string{"-network", ""}},
+		{name: "invalid log level", env: map[string]string{"CRG_LOG_LEVEL": "verbose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, "CRG_CLIENT", "config-test")
+			setEnv(t, "CRG_BLOCKCHAIN", "env")
+			setEnv(t, "CRG_NETWORK", "env")
+			for k, v := range tt.env {
+				setEnv(t, k, v)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			loader := NewLoader(fs)
+			if err := fs.Parse(tt.flags); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loader.Load(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// setEnv sets the environment variable for the duration of the test
+func setEnv(t *testing.T, key, value string) {
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, prev)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
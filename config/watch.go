@@ -0,0 +1,85 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval at which the configuration file is checked for changes
+const DefaultWatchInterval = 5 * time.Second
+
+// Watch calls reload when the process receives SIGHUP or, if path is not empty,
+// when the configuration file modification time or size changes.
+// It blocks until ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration, reload func()) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := fileVersion(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = fileVersion(path)
+			reload()
+		case <-ticker.C:
+			if path == "" {
+				continue
+			}
+			current := fileVersion(path)
+			if current == last {
+				continue
+			}
+			last = current
+			reload()
+		}
+	}
+}
+
+// version identifies a version of a file
+type version struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileVersion returns the version of the file, the zero version if it cannot be read
+func fileVersion(path string) version {
+	if path == "" {
+		return version{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return version{}
+	}
+	return version{modTime: info.ModTime(), size: info.Size()}
+}
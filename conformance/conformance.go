@@ -0,0 +1,144 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package conformance checks a client implementation against the rosetta specification.
+// It serves the client through the gateway in-process and, as rosetta-cli does against
+// a live network, checks the Data API responses and runs the construction flow, producing
+// a pass/fail Report which client authors can assert in go test.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/client"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// DefaultMaxBlocks is the default number of blocks checked by the Data API checks
+const DefaultMaxBlocks = 100
+
+// Config defines the client to check and how to check it
+type Config struct {
+	// Network identifies the network served by the client
+	Network *types.NetworkIdentifier
+	// Client is the implementation to check
+	Client crgtypes.Client
+	// ClientV2 is the context aware implementation to check, it takes precedence over Client
+	ClientV2 crgtypes.ClientV2
+	// MaxBlocks is the number of blocks, ending at the current one, checked
+	// for continuity and balance reconciliation, defaults to DefaultMaxBlocks
+	MaxBlocks int64
+	// Construction configures the construction flow, if nil the flow is skipped
+	Construction *ConstructionConfig
+}
+
+// ConstructionConfig defines the transaction built by the construction flow
+type ConstructionConfig struct {
+	// Operations are the operations of the transaction
+	Operations []*types.Operation
+	// Signers sign the payloads of the transaction, each payload is signed
+	// by the signer whose public key derives the payload account
+	Signers []Signer
+	// Submit enables /construction/submit, which changes the state of the chain
+	Submit bool
+	// Commit, if set, is called after the submission to include the transaction in a block,
+	// the current block must then contain the transaction
+	Commit func(ctx context.Context) error
+}
+
+// Signer signs construction payloads
+type Signer interface {
+	// PublicKey returns the public key of the signer
+	PublicKey() *types.PublicKey
+	// Sign signs the payload
+	Sign(payload *types.SigningPayload) (*types.Signature, error)
+}
+
+// runner holds the state of a conformance run
+type runner struct {
+	cfg      Config
+	online   *client.APIClient
+	offline  *client.APIClient
+	asserter *asserter.Asserter
+	report   *Report
+}
+
+// Run serves the client through the online and offline adapters and runs the checks,
+// the returned error reports failures in setting up the run, not check failures
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Network == nil || (cfg.Client == nil && cfg.ClientV2 == nil) {
+		return Report{}, fmt.Errorf("network and client are required")
+	}
+	if cfg.MaxBlocks <= 0 {
+		cfg.MaxBlocks = DefaultMaxBlocks
+	}
+
+	online, err := newServer(ctx, cfg, false)
+	if err != nil {
+		return Report{}, fmt.Errorf("cannot build online server: %w", err)
+	}
+	defer online.Close()
+	offline, err := newServer(ctx, cfg, true)
+	if err != nil {
+		return Report{}, fmt.Errorf("cannot build offline server: %w", err)
+	}
+	defer offline.Close()
+
+	r := &runner{
+		cfg:     cfg,
+		online:  newAPIClient(online),
+		offline: newAPIClient(offline),
+		report:  new(Report),
+	}
+	r.checkData(ctx)
+	r.checkConstruction(ctx)
+	return *r.report, nil
+}
+
+func newServer(ctx context.Context, cfg Config, offline bool) (*httptest.Server, error) {
+	srv, err := server.NewServerContext(ctx, server.Settings{
+		Network:   cfg.Network,
+		Client:    cfg.Client,
+		ClientV2:  cfg.ClientV2,
+		Offline:   offline,
+		Retries:   1,
+		RetryWait: time.Millisecond,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(srv.Handler()), nil
+}
+
+func newAPIClient(srv *httptest.Server) *client.APIClient {
+	return client.NewAPIClient(client.NewConfiguration(srv.URL, "conformance", srv.Client()))
+}
+
+// callError returns the error of a rosetta API call, if any
+func callError(rosErr *types.Error, err error) error {
+	if rosErr != nil {
+		return fmt.Errorf("rosetta error %d: %s %v", rosErr.Code, rosErr.Message, rosErr.Details)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package conformance_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	"github.com/tendermint/cosmos-rosetta-gateway/conformance"
+)
+
+var _ conformance.Signer = (*clienttest.Account)(nil)
+
+func TestRunClienttest(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	client.CommitBlock()
+	if _, err := client.Transfer(alice, bob.Address, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	client.CommitBlock()
+
+	transfer := []*types.Operation{
+		{OperationIdentifier: &types.OperationIdentifier{Index: 0}, Type: clienttest.OpTransfer, Account: alice.Identifier(), Amount: &types.Amount{Value: "-5", Currency: client.Currency()}},
+		{OperationIdentifier: &types.OperationIdentifier{Index: 1}, Type: clienttest.OpTransfer, Account: bob.Identifier(), Amount: &types.Amount{Value: "5", Currency: client.Currency()}},
+	}
+
+	tests := []struct {
+		name         string
+		construction *conformance.ConstructionConfig
+		// wantStatus is the expected status of the listed checks
+		wantStatus map[string]conformance.Status
+	}{
+		{
+			name: "data only",
+			wantStatus: map[string]conformance.Status{
+				"construction/derive": conformance.StatusSkip,
+			},
+		},
+		{
+			name: "construction without submit",
+			construction: &conformance.ConstructionConfig{
+				Operations: transfer,
+				Signers:    []conformance.Signer{alice},
+			},
+			wantStatus: map[string]conformance.Status{
+				"construction/hash":   conformance.StatusPass,
+				"construction/submit": conformance.StatusSkip,
+			},
+		},
+		{
+			name: "construction with submit and commit",
+			construction: &conformance.ConstructionConfig{
+				Operations: transfer,
+				Signers:    []conformance.Signer{alice},
+				Submit:     true,
+				Commit: func(context.Context) error {
+					client.CommitBlock()
+					return nil
+				},
+			},
+			wantStatus: map[string]conformance.Status{
+				"construction/submit":    conformance.StatusPass,
+				"construction/inclusion": conformance.StatusPass,
+			},
+		},
+		{
+			name: "missing signer",
+			construction: &conformance.ConstructionConfig{
+				Operations: transfer,
+				Signers:    []conformance.Signer{bob},
+			},
+			wantStatus: map[string]conformance.Status{
+				"construction/preprocess": conformance.StatusFail,
+				"construction/combine":    conformance.StatusSkip,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := conformance.Run(context.Background(), conformance.Config{
+				Network:      client.Network(),
+				Client:       client,
+				Construction: tt.construction,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			statuses := make(map[string]conformance.Status, len(report.Results))
+			for _, res := range report.Results {
+				statuses[res.Name] = res.Status
+			}
+			for name, want := range tt.wantStatus {
+				if statuses[name] != want {
+					t.Errorf("check %s: expected %s, got %s\n%s", name, want, statuses[name], report)
+				}
+			}
+			// only the failures listed are expected
+			for _, res := range report.Failures() {
+				if tt.wantStatus[res.Name] != conformance.StatusFail {
+					t.Errorf("unexpected failure of %s: %s", res.Name, res.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestRunRequiresClient(t *testing.T) {
+	if _, err := conformance.Run(context.Background(), conformance.Config{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,362 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	checkDerive      = "construction/derive"
+	checkPreprocess  = "construction/preprocess"
+	checkMetadata    = "construction/metadata"
+	checkPayloads    = "construction/payloads"
+	checkParse       = "construction/parse_unsigned"
+	checkCombine     = "construction/combine"
+	checkParseSigned = "construction/parse_signed"
+	checkHash        = "construction/hash"
+	checkSubmit      = "construction/submit"
+	checkInclusion   = "construction/inclusion"
+)
+
+// constructionSteps lists the construction checks in execution order
+var constructionSteps = []string{
+	checkDerive, checkPreprocess, checkMetadata, checkPayloads, checkParse,
+	checkCombine, checkParseSigned, checkHash, checkSubmit, checkInclusion,
+}
+
+// construction holds the state carried between the construction steps
+type construction struct {
+	cfg        *ConstructionConfig
+	signers    map[string]Signer // indexed by derived address
+	options    map[string]interface{}
+	publicKeys []*types.PublicKey
+	metadata   map[string]interface{}
+	unsigned   string
+	payloads   []*types.SigningPayload
+	signed     string
+	hash       *types.TransactionIdentifier
+}
+
+// checkConstruction runs the construction flow, each step depends on the previous ones
+// so the steps following a failure are skipped
+func (r *runner) checkConstruction(ctx context.Context) {
+	if r.cfg.Construction == nil {
+		r.skipAll(constructionSteps, "construction not configured")
+		return
+	}
+	c := &construction{cfg: r.cfg.Construction, signers: make(map[string]Signer)}
+	steps := map[string]func(context.Context, *construction) error{
+		checkDerive:      r.derive,
+		checkPreprocess:  r.preprocess,
+		checkMetadata:    r.metadata,
+		checkPayloads:    r.payloads,
+		checkParse:       r.parseUnsigned,
+		checkCombine:     r.combine,
+		checkParseSigned: r.parseSigned,
+		checkHash:        r.hash,
+		checkSubmit:      r.submit,
+		checkInclusion:   r.inclusion,
+	}
+	for i, name := range constructionSteps {
+		if name == checkSubmit && !c.cfg.Submit {
+			r.skipAll(constructionSteps[i:], "submission not enabled")
+			return
+		}
+		if name == checkInclusion && c.cfg.Commit == nil {
+			r.report.skip(name, "commit not configured")
+			return
+		}
+		if err := steps[name](ctx, c); err != nil {
+			r.report.fail(name, "%v", err)
+			r.skipAll(constructionSteps[i+1:], "%s failed", name)
+			return
+		}
+		r.report.pass(name)
+	}
+}
+
+func (r *runner) derive(ctx context.Context, c *construction) error {
+	for _, signer := range c.cfg.Signers {
+		resp, rosErr, err := r.offline.ConstructionAPI.ConstructionDerive(ctx, &types.ConstructionDeriveRequest{
+			NetworkIdentifier: r.cfg.Network,
+			PublicKey:         signer.PublicKey(),
+		})
+		if err := callError(rosErr, err); err != nil {
+			return err
+		}
+		if err := asserter.ConstructionDeriveResponse(resp); err != nil {
+			return err
+		}
+		c.signers[resp.AccountIdentifier.Address] = signer
+	}
+	return nil
+}
+
+func (r *runner) preprocess(ctx context.Context, c *construction) error {
+	resp, rosErr, err := r.offline.ConstructionAPI.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: r.cfg.Network,
+		Operations:        c.cfg.Operations,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.ConstructionPreprocessResponse(resp); err != nil {
+		return err
+	}
+	for _, account := range resp.RequiredPublicKeys {
+		signer, ok := c.signers[account.Address]
+		if !ok {
+			return fmt.Errorf("no signer derives the required public key of %s", account.Address)
+		}
+		c.publicKeys = append(c.publicKeys, signer.PublicKey())
+	}
+	c.options = resp.Options
+	return nil
+}
+
+func (r *runner) metadata(ctx context.Context, c *construction) error {
+	resp, rosErr, err := r.online.ConstructionAPI.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: r.cfg.Network,
+		Options:           c.options,
+		PublicKeys:        c.publicKeys,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.ConstructionMetadataResponse(resp); err != nil {
+		return err
+	}
+	c.metadata = resp.Metadata
+	return nil
+}
+
+func (r *runner) payloads(ctx context.Context, c *construction) error {
+	resp, rosErr, err := r.offline.ConstructionAPI.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: r.cfg.Network,
+		Operations:        c.cfg.Operations,
+		Metadata:          c.metadata,
+		PublicKeys:        c.publicKeys,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.ConstructionPayloadsResponse(resp); err != nil {
+		return err
+	}
+	c.unsigned = resp.UnsignedTransaction
+	c.payloads = resp.Payloads
+	return nil
+}
+
+func (r *runner) parseUnsigned(ctx context.Context, c *construction) error {
+	_, err := r.parse(ctx, c, false)
+	return err
+}
+
+func (r *runner) combine(ctx context.Context, c *construction) error {
+	sigs := make([]*types.Signature, len(c.payloads))
+	for i, payload := range c.payloads {
+		address := payloadAddress(payload)
+		signer, ok := c.signers[address]
+		if !ok {
+			return fmt.Errorf("no signer for the payload of %s", address)
+		}
+		sig, err := signer.Sign(payload)
+		if err != nil {
+			return fmt.Errorf("cannot sign the payload of %s: %w", address, err)
+		}
+		sigs[i] = sig
+	}
+	resp, rosErr, err := r.offline.ConstructionAPI.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   r.cfg.Network,
+		UnsignedTransaction: c.unsigned,
+		Signatures:          sigs,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.ConstructionCombineResponse(resp); err != nil {
+		return err
+	}
+	c.signed = resp.SignedTransaction
+	return nil
+}
+
+func (r *runner) parseSigned(ctx context.Context, c *construction) error {
+	signers, err := r.parse(ctx, c, true)
+	if err != nil {
+		return err
+	}
+	expected := make([]string, len(c.payloads))
+	for i, payload := range c.payloads {
+		expected[i] = payloadAddress(payload)
+	}
+	if !sameStrings(signers, expected) {
+		return fmt.Errorf("parsed signers %v, payloads are signed by %v", signers, expected)
+	}
+	return nil
+}
+
+// parse parses the unsigned or signed transaction, checks the operations match the requested
+// ones and returns the addresses of the signers
+func (r *runner) parse(ctx context.Context, c *construction, signed bool) ([]string, error) {
+	tx := c.unsigned
+	if signed {
+		tx = c.signed
+	}
+	resp, rosErr, err := r.offline.ConstructionAPI.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: r.cfg.Network,
+		Signed:            signed,
+		Transaction:       tx,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return nil, err
+	}
+	if r.asserter != nil {
+		if err := r.asserter.ConstructionParseResponse(resp, signed); err != nil {
+			return nil, err
+		}
+	}
+	if err := matchOperations(c.cfg.Operations, resp.Operations); err != nil {
+		return nil, err
+	}
+	signers := make([]string, len(resp.AccountIdentifierSigners))
+	for i, account := range resp.AccountIdentifierSigners {
+		signers[i] = account.Address
+	}
+	return signers, nil
+}
+
+func (r *runner) hash(ctx context.Context, c *construction) error {
+	resp, rosErr, err := r.offline.ConstructionAPI.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: r.cfg.Network,
+		SignedTransaction: c.signed,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.TransactionIdentifierResponse(resp); err != nil {
+		return err
+	}
+	c.hash = resp.TransactionIdentifier
+	return nil
+}
+
+func (r *runner) submit(ctx context.Context, c *construction) error {
+	resp, rosErr, err := r.online.ConstructionAPI.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: r.cfg.Network,
+		SignedTransaction: c.signed,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return err
+	}
+	if err := asserter.TransactionIdentifierResponse(resp); err != nil {
+		return err
+	}
+	if resp.TransactionIdentifier.Hash != c.hash.Hash {
+		return fmt.Errorf("submit returned hash %s, /construction/hash returned %s", resp.TransactionIdentifier.Hash, c.hash.Hash)
+	}
+	return nil
+}
+
+// inclusion commits the submitted transaction and checks the current block contains it with the requested operations
+func (r *runner) inclusion(ctx context.Context, c *construction) error {
+	if err := c.cfg.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	if r.asserter == nil {
+		return fmt.Errorf("%s failed, blocks cannot be validated", checkNetworkStatus)
+	}
+	status, _, err := r.networkStatusAndOptions(ctx)
+	if err != nil {
+		return err
+	}
+	block, err := r.block(ctx, &types.PartialBlockIdentifier{Index: &status.CurrentBlockIdentifier.Index})
+	if err != nil {
+		return err
+	}
+	for _, tx := range block.Transactions {
+		if tx.TransactionIdentifier.Hash == c.hash.Hash {
+			return matchOperations(c.cfg.Operations, tx.Operations)
+		}
+	}
+	return fmt.Errorf("tx %s not found in the current block %d", c.hash.Hash, block.BlockIdentifier.Index)
+}
+
+// matchOperations checks the parsed operations match the expected ones, regardless of their
+// order, identifiers, statuses and metadata
+func matchOperations(expected, parsed []*types.Operation) error {
+	keys := make(map[string]int, len(expected))
+	for _, op := range expected {
+		keys[operationKey(op)]++
+	}
+	for _, op := range parsed {
+		key := operationKey(op)
+		if keys[key] == 0 {
+			return fmt.Errorf("unexpected operation %s", key)
+		}
+		keys[key]--
+	}
+	for key, n := range keys {
+		if n != 0 {
+			return fmt.Errorf("missing operation %s", key)
+		}
+	}
+	return nil
+}
+
+func operationKey(op *types.Operation) string {
+	key := op.Type
+	if op.Account != nil {
+		key += " " + types.AccountString(op.Account)
+	}
+	if op.Amount != nil {
+		key += " " + op.Amount.Value + " " + types.CurrencyString(op.Amount.Currency)
+	}
+	return key
+}
+
+func payloadAddress(payload *types.SigningPayload) string {
+	if payload.AccountIdentifier == nil {
+		return ""
+	}
+	return payload.AccountIdentifier.Address
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
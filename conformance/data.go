@@ -0,0 +1,318 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	checkNetworkList     = "data/network_list"
+	checkNetworkStatus   = "data/network_status"
+	checkGenesisBlock    = "data/genesis_block"
+	checkOldestBlock     = "data/oldest_block"
+	checkBlockContinuity = "data/block_continuity"
+	checkReconciliation  = "data/balance_reconciliation"
+)
+
+// balanceChanges are the balance changes of a block, indexed by account then currency
+type balanceChanges map[string]map[string]*balanceChange
+
+type balanceChange struct {
+	account  *types.AccountIdentifier
+	currency *types.Currency
+	amount   *big.Int
+}
+
+func (r *runner) checkData(ctx context.Context) {
+	dependents := []string{checkNetworkStatus, checkGenesisBlock, checkOldestBlock, checkBlockContinuity, checkReconciliation}
+
+	list, rosErr, err := r.online.NetworkAPI.NetworkList(ctx, &types.MetadataRequest{})
+	if err := callError(rosErr, err); err != nil {
+		r.report.fail(checkNetworkList, "%v", err)
+	} else if !containsNetwork(list.NetworkIdentifiers, r.cfg.Network) {
+		r.report.fail(checkNetworkList, "network %s is not listed", types.PrintStruct(r.cfg.Network))
+	} else {
+		r.report.pass(checkNetworkList)
+	}
+
+	status, options, err := r.networkStatusAndOptions(ctx)
+	if err != nil {
+		r.report.fail(checkNetworkStatus, "%v", err)
+		r.skipAll(dependents[1:], "%s failed", checkNetworkStatus)
+		return
+	}
+	r.asserter, err = asserter.NewClientWithResponses(r.cfg.Network, status, options)
+	if err != nil {
+		r.report.fail(checkNetworkStatus, "%v", err)
+		r.skipAll(dependents[1:], "%s failed", checkNetworkStatus)
+		return
+	}
+	r.report.pass(checkNetworkStatus)
+
+	genesis, err := r.block(ctx, &types.PartialBlockIdentifier{Index: &status.GenesisBlockIdentifier.Index})
+	switch {
+	case err != nil:
+		r.report.fail(checkGenesisBlock, "%v", err)
+	case !sameBlock(genesis.BlockIdentifier, status.GenesisBlockIdentifier):
+		r.report.fail(checkGenesisBlock, "block at genesis index is %s, network status reports %s",
+			types.PrintStruct(genesis.BlockIdentifier), types.PrintStruct(status.GenesisBlockIdentifier))
+	case !sameBlock(genesis.ParentBlockIdentifier, genesis.BlockIdentifier):
+		r.report.fail(checkGenesisBlock, "the parent of the genesis block must be the genesis block itself")
+	default:
+		r.report.pass(checkGenesisBlock)
+	}
+
+	first := status.GenesisBlockIdentifier.Index
+	if oldest := status.OldestBlockIdentifier; oldest == nil {
+		r.report.skip(checkOldestBlock, "no oldest block reported")
+	} else if err := r.checkOldestBlock(ctx, status); err != nil {
+		r.report.fail(checkOldestBlock, "%v", err)
+	} else {
+		first = oldest.Index
+		r.report.pass(checkOldestBlock)
+	}
+
+	current := status.CurrentBlockIdentifier.Index
+	if start := current - r.cfg.MaxBlocks + 1; start > first {
+		first = start
+	}
+	changes, err := r.checkBlockContinuity(ctx, first, current)
+	if err != nil {
+		r.report.fail(checkBlockContinuity, "%v", err)
+		r.report.skip(checkReconciliation, "%s failed", checkBlockContinuity)
+		return
+	}
+	r.report.pass(checkBlockContinuity)
+
+	if err := r.reconcileBalances(ctx, first, current, changes); err != nil {
+		r.report.fail(checkReconciliation, "%v", err)
+		return
+	}
+	r.report.pass(checkReconciliation)
+}
+
+func (r *runner) networkStatusAndOptions(ctx context.Context) (*types.NetworkStatusResponse, *types.NetworkOptionsResponse, error) {
+	req := &types.NetworkRequest{NetworkIdentifier: r.cfg.Network}
+	status, rosErr, err := r.online.NetworkAPI.NetworkStatus(ctx, req)
+	if err := callError(rosErr, err); err != nil {
+		return nil, nil, fmt.Errorf("network status: %w", err)
+	}
+	options, rosErr, err := r.online.NetworkAPI.NetworkOptions(ctx, req)
+	if err := callError(rosErr, err); err != nil {
+		return nil, nil, fmt.Errorf("network options: %w", err)
+	}
+	return status, options, nil
+}
+
+func (r *runner) checkOldestBlock(ctx context.Context, status *types.NetworkStatusResponse) error {
+	oldest := status.OldestBlockIdentifier
+	if oldest.Index < status.GenesisBlockIdentifier.Index || oldest.Index > status.CurrentBlockIdentifier.Index {
+		return fmt.Errorf("oldest block index %d is not between genesis %d and current %d",
+			oldest.Index, status.GenesisBlockIdentifier.Index, status.CurrentBlockIdentifier.Index)
+	}
+	block, err := r.block(ctx, &types.PartialBlockIdentifier{Index: &oldest.Index})
+	if err != nil {
+		return err
+	}
+	if !sameBlock(block.BlockIdentifier, oldest) {
+		return fmt.Errorf("block at oldest index is %s, network status reports %s",
+			types.PrintStruct(block.BlockIdentifier), types.PrintStruct(oldest))
+	}
+	return nil
+}
+
+// checkBlockContinuity checks the blocks between first and last are valid and linked
+// to their parents, it returns the balance changes of each block indexed by height
+func (r *runner) checkBlockContinuity(ctx context.Context, first, last int64) (map[int64]balanceChanges, error) {
+	changes := make(map[int64]balanceChanges, last-first+1)
+	var previous *types.Block
+	for index := first; index <= last; index++ {
+		i := index
+		block, err := r.block(ctx, &types.PartialBlockIdentifier{Index: &i})
+		if err != nil {
+			return nil, err
+		}
+		if block.BlockIdentifier.Index != index {
+			return nil, fmt.Errorf("requested block %d, got %d", index, block.BlockIdentifier.Index)
+		}
+		byHash, err := r.block(ctx, &types.PartialBlockIdentifier{Hash: &block.BlockIdentifier.Hash})
+		if err != nil {
+			return nil, fmt.Errorf("block %d by hash: %w", index, err)
+		}
+		if types.Hash(byHash) != types.Hash(block) {
+			return nil, fmt.Errorf("block %d differs when requested by hash", index)
+		}
+		if previous != nil {
+			if !sameBlock(block.ParentBlockIdentifier, previous.BlockIdentifier) {
+				return nil, fmt.Errorf("parent of block %d is %s, previous block is %s", index,
+					types.PrintStruct(block.ParentBlockIdentifier), types.PrintStruct(previous.BlockIdentifier))
+			}
+			if block.Timestamp < previous.Timestamp {
+				return nil, fmt.Errorf("timestamp of block %d is before the one of its parent", index)
+			}
+		}
+		changes[index], err = r.balanceChanges(block)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", index, err)
+		}
+		previous = block
+	}
+	return changes, nil
+}
+
+// balanceChanges sums the amounts of the successful operations of the block
+func (r *runner) balanceChanges(block *types.Block) (balanceChanges, error) {
+	changes := make(balanceChanges)
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if op.Account == nil || op.Amount == nil {
+				continue
+			}
+			ok, err := r.asserter.OperationSuccessful(op)
+			if err != nil {
+				return nil, fmt.Errorf("tx %s: %w", tx.TransactionIdentifier.Hash, err)
+			}
+			if !ok {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(op.Amount.Value, 10)
+			if !ok {
+				return nil, fmt.Errorf("tx %s: invalid amount %s", tx.TransactionIdentifier.Hash, op.Amount.Value)
+			}
+			account := types.AccountString(op.Account)
+			if changes[account] == nil {
+				changes[account] = make(map[string]*balanceChange)
+			}
+			currency := types.CurrencyString(op.Amount.Currency)
+			change, ok := changes[account][currency]
+			if !ok {
+				change = &balanceChange{account: op.Account, currency: op.Amount.Currency, amount: new(big.Int)}
+				changes[account][currency] = change
+			}
+			change.amount.Add(change.amount, amount)
+		}
+	}
+	return changes, nil
+}
+
+// reconcileBalances checks that, for every account changed by the blocks in [first, last], the balance
+// difference between each block in (first, last] and its parent matches the operations of the block,
+// accounts untouched by a block must keep their balance
+func (r *runner) reconcileBalances(ctx context.Context, first, last int64, changes map[int64]balanceChanges) error {
+	accounts := make(map[string]*balanceChange)
+	for _, block := range changes {
+		for _, currencies := range block {
+			for _, change := range currencies {
+				accounts[types.AccountString(change.account)+" "+types.CurrencyString(change.currency)] = change
+			}
+		}
+	}
+
+	for _, account := range accounts {
+		before, err := r.balance(ctx, account.account, account.currency, first)
+		if err != nil {
+			return err
+		}
+		for index := first + 1; index <= last; index++ {
+			after, err := r.balance(ctx, account.account, account.currency, index)
+			if err != nil {
+				return err
+			}
+			expected := new(big.Int)
+			if change, ok := changes[index][types.AccountString(account.account)][types.CurrencyString(account.currency)]; ok {
+				expected = change.amount
+			}
+			if diff := new(big.Int).Sub(after, before); diff.Cmp(expected) != 0 {
+				return fmt.Errorf("balance of %s changed by %s %s at block %d, operations account for %s",
+					types.AccountString(account.account), diff, account.currency.Symbol, index, expected)
+			}
+			before = after
+		}
+	}
+	return nil
+}
+
+func (r *runner) balance(ctx context.Context, account *types.AccountIdentifier, currency *types.Currency, index int64) (*big.Int, error) {
+	block := &types.PartialBlockIdentifier{Index: &index}
+	resp, rosErr, err := r.online.AccountAPI.AccountBalance(ctx, &types.AccountBalanceRequest{
+		NetworkIdentifier: r.cfg.Network,
+		AccountIdentifier: account,
+		BlockIdentifier:   block,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return nil, fmt.Errorf("balance of %s at block %d: %w", types.AccountString(account), index, err)
+	}
+	if err := asserter.AccountBalanceResponse(block, resp); err != nil {
+		return nil, fmt.Errorf("balance of %s at block %d: %w", types.AccountString(account), index, err)
+	}
+	for _, amount := range resp.Balances {
+		if types.Hash(amount.Currency) != types.Hash(currency) {
+			continue
+		}
+		value, ok := new(big.Int).SetString(amount.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %s of %s", amount.Value, types.AccountString(account))
+		}
+		return value, nil
+	}
+	return new(big.Int), nil
+}
+
+// block fetches the block and validates it
+func (r *runner) block(ctx context.Context, id *types.PartialBlockIdentifier) (*types.Block, error) {
+	resp, rosErr, err := r.online.BlockAPI.Block(ctx, &types.BlockRequest{
+		NetworkIdentifier: r.cfg.Network,
+		BlockIdentifier:   id,
+	})
+	if err := callError(rosErr, err); err != nil {
+		return nil, fmt.Errorf("block %s: %w", types.PrintStruct(id), err)
+	}
+	if resp.Block == nil {
+		return nil, fmt.Errorf("block %s: empty response", types.PrintStruct(id))
+	}
+	if err := r.asserter.Block(resp.Block); err != nil {
+		return nil, fmt.Errorf("block %s: %w", types.PrintStruct(id), err)
+	}
+	return resp.Block, nil
+}
+
+func (r *runner) skipAll(names []string, format string, args ...interface{}) {
+	for _, name := range names {
+		r.report.skip(name, format, args...)
+	}
+}
+
+func containsNetwork(networks []*types.NetworkIdentifier, network *types.NetworkIdentifier) bool {
+	for _, n := range networks {
+		if types.Hash(n) == types.Hash(network) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameBlock(a, b *types.BlockIdentifier) bool {
+	return a != nil && b != nil && a.Index == b.Index && a.Hash == b.Hash
+}
@@ -0,0 +1,114 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package conformance
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// StatusPass means the check succeeded
+	StatusPass Status = "pass"
+	// StatusFail means the check found a violation
+	StatusFail Status = "fail"
+	// StatusSkip means the check was not run, because it is not configured
+	// or because a check it depends on failed
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+}
+
+// Report contains the results of a conformance run, in execution order
+type Report struct {
+	Results []Result
+}
+
+// Passed reports if no check failed
+func (r Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the results of the failed checks
+func (r Report) Failures() []Result {
+	var failures []Result
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			failures = append(failures, res)
+		}
+	}
+	return failures
+}
+
+// String formats the report one check per line
+func (r Report) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, "%-4s %s", res.Status, res.Name)
+		if res.Message != "" {
+			fmt.Fprintf(&b, ": %s", res.Message)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// TB is the subset of testing.TB used by Assert
+type TB interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Assert logs the report and marks the test as failed if any check failed
+func (r Report) Assert(t TB) {
+	t.Helper()
+	t.Logf("conformance report:\n%s", r)
+	for _, res := range r.Failures() {
+		t.Errorf("%s: %s", res.Name, res.Message)
+	}
+}
+
+func (r *Report) add(name string, status Status, format string, args ...interface{}) {
+	r.Results = append(r.Results, Result{
+		Name:    name,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *Report) pass(name string) {
+	r.add(name, StatusPass, "")
+}
+
+func (r *Report) fail(name string, format string, args ...interface{}) {
+	r.add(name, StatusFail, format, args...)
+}
+
+func (r *Report) skip(name string, format string, args ...interface{}) {
+	r.add(name, StatusSkip, format, args...)
+}
@@ -0,0 +1,208 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+// abci.go contains the translation of cosmos-sdk ABCI errors, identified
+// by their codespace and code, to rosetta errors
+
+import (
+	"net/http"
+	"sync"
+)
+
+// SDKCodespace is the codespace of the cosmos-sdk root errors
+const SDKCodespace = "sdk"
+
+// UndefinedCodespace is the codespace of the cosmos-sdk internal errors and panics
+const UndefinedCodespace = "undefined"
+
+// ABCI errors list, those are the rosetta errors dedicated to
+// cosmos-sdk ABCI errors wallets are expected to react to
+var (
+	// ErrInsufficientFunds is returned when the account cannot pay for the transaction
+	ErrInsufficientFunds = RegisterErrorWithHTTPStatus(100, "insufficient funds", false, "returned when the account balance is not enough to perform the transaction", http.StatusBadRequest)
+	// ErrSequenceMismatch is returned when the transaction account sequence is not the expected one,
+	// it is retriable as the transaction can be signed again with the updated sequence
+	ErrSequenceMismatch = RegisterErrorWithHTTPStatus(101, "account sequence mismatch", true, "returned when the account sequence of the transaction is not the expected one", http.StatusConflict)
+	// ErrOutOfGas is returned when the transaction runs out of gas
+	ErrOutOfGas = RegisterErrorWithHTTPStatus(102, "out of gas", false, "returned when the transaction gas limit is not enough to execute it", http.StatusBadRequest)
+	// ErrInsufficientFee is returned when the transaction fee is lower than the minimum required
+	ErrInsufficientFee = RegisterErrorWithHTTPStatus(103, "insufficient fee", false, "returned when the transaction fee is lower than the minimum required by the node", http.StatusBadRequest)
+	// ErrTxInMempool is returned when the transaction is already in the mempool
+	ErrTxInMempool = RegisterErrorWithHTTPStatus(104, "tx already in mempool", false, "returned when the transaction was already submitted", http.StatusConflict)
+	// ErrMempoolFull is returned when the node mempool cannot accept more transactions
+	ErrMempoolFull = RegisterErrorWithHTTPStatus(105, "mempool is full", true, "returned when the node mempool is full", http.StatusServiceUnavailable)
+	// ErrTxTooLarge is returned when the transaction exceeds the maximum size
+	ErrTxTooLarge = RegisterErrorWithHTTPStatus(106, "tx too large", false, "returned when the transaction size exceeds the maximum allowed", http.StatusRequestEntityTooLarge)
+	// ErrUnauthorized is returned when the transaction signatures are not valid
+	ErrUnauthorized = RegisterErrorWithHTTPStatus(107, "unauthorized", false, "returned when the transaction signatures cannot be verified", http.StatusBadRequest)
+	// ErrTxTimeoutHeight is returned when the transaction timeout height was reached
+	ErrTxTimeoutHeight = RegisterErrorWithHTTPStatus(108, "tx timeout height reached", false, "returned when the transaction timeout height is lower than the current height", http.StatusBadRequest)
+	// ErrInvalidChainID is returned when the transaction was signed for another chain
+	ErrInvalidChainID = RegisterErrorWithHTTPStatus(109, "invalid chain id", false, "returned when the transaction chain id does not match the node one", http.StatusBadRequest)
+	// ErrSendDisabled is returned when transfers of the denom are disabled
+	ErrSendDisabled = RegisterErrorWithHTTPStatus(110, "send disabled", false, "returned when transfers are disabled for the denom", http.StatusForbidden)
+	// ErrValidatorNotFound is returned when the validator does not exist
+	ErrValidatorNotFound = RegisterErrorWithHTTPStatus(111, "validator not found", false, "returned when the validator does not exist", http.StatusNotFound)
+	// ErrValidatorJailed is returned when the validator is jailed
+	ErrValidatorJailed = RegisterErrorWithHTTPStatus(112, "validator jailed", false, "returned when the validator is jailed", http.StatusBadRequest)
+)
+
+// abciKey identifies an ABCI error
+type abciKey struct {
+	codespace string
+	code      uint32
+}
+
+// defaultABCIErrors maps the standard cosmos-sdk errors to rosetta errors
+var defaultABCIErrors = map[abciKey]*Error{
+	// internal errors and recovered panics
+	{UndefinedCodespace, 1}:      ErrInternal,
+	{UndefinedCodespace, 111222}: ErrInternal,
+	// sdk root errors, they include the errors of the auth ante handlers
+	// such as signature, sequence, fee and gas failures
+	{SDKCodespace, 2}:  ErrInvalidTransaction,
+	{SDKCodespace, 3}:  ErrSequenceMismatch,
+	{SDKCodespace, 4}:  ErrUnauthorized,
+	{SDKCodespace, 5}:  ErrInsufficientFunds,
+	{SDKCodespace, 6}:  ErrBadArgument,
+	{SDKCodespace, 7}:  ErrInvalidAddress,
+	{SDKCodespace, 8}:  ErrInvalidPubkey,
+	{SDKCodespace, 9}:  ErrNotFound,
+	{SDKCodespace, 10}: ErrBadArgument,
+	{SDKCodespace, 11}: ErrOutOfGas,
+	{SDKCodespace, 12}: ErrInvalidMemo,
+	{SDKCodespace, 13}: ErrInsufficientFee,
+	{SDKCodespace, 14}: ErrInvalidTransaction,
+	{SDKCodespace, 15}: ErrInvalidTransaction,
+	{SDKCodespace, 16}: ErrCodec,
+	{SDKCodespace, 17}: ErrCodec,
+	{SDKCodespace, 18}: ErrBadArgument,
+	{SDKCodespace, 19}: ErrTxInMempool,
+	{SDKCodespace, 20}: ErrMempoolFull,
+	{SDKCodespace, 21}: ErrTxTooLarge,
+	{SDKCodespace, 22}: ErrNotFound,
+	{SDKCodespace, 24}: ErrUnauthorized,
+	{SDKCodespace, 26}: ErrBadArgument,
+	{SDKCodespace, 28}: ErrInvalidChainID,
+	{SDKCodespace, 30}: ErrTxTimeoutHeight,
+	{SDKCodespace, 32}: ErrSequenceMismatch,
+	{SDKCodespace, 38}: ErrNotFound,
+	{SDKCodespace, 41}: ErrBadArgument,
+	// bank
+	{"bank", 2}: ErrInvalidOperation,
+	{"bank", 3}: ErrInvalidOperation,
+	{"bank", 4}: ErrInvalidOperation,
+	{"bank", 5}: ErrSendDisabled,
+	// staking
+	{"staking", 2}: ErrInvalidAddress,
+	{"staking", 3}: ErrValidatorNotFound,
+	{"staking", 7}: ErrValidatorJailed,
+	// distribution
+	{"distribution", 2}:  ErrInvalidAddress,
+	{"distribution", 3}:  ErrInvalidAddress,
+	{"distribution", 4}:  ErrInvalidAddress,
+	{"distribution", 5}:  ErrNotFound,
+	{"distribution", 6}:  ErrNotFound,
+	{"distribution", 7}:  ErrNotFound,
+	{"distribution", 8}:  ErrPermissionDenied,
+	{"distribution", 9}:  ErrInsufficientFunds,
+	{"distribution", 10}: ErrBadArgument,
+	{"distribution", 11}: ErrInvalidAddress,
+	{"distribution", 12}: ErrValidatorNotFound,
+	{"distribution", 13}: ErrNotFound,
+	// gov
+	{"gov", 2}: ErrNotFound,
+	{"gov", 3}: ErrInvalidOperation,
+	{"gov", 4}: ErrInvalidOperation,
+	{"gov", 5}: ErrBadArgument,
+	{"gov", 6}: ErrBadArgument,
+	{"gov", 7}: ErrBadArgument,
+	{"gov", 9}: ErrBadArgument,
+	// slashing
+	{"slashing", 2}: ErrValidatorNotFound,
+	{"slashing", 3}: ErrInvalidAddress,
+	{"slashing", 4}: ErrValidatorJailed,
+	{"slashing", 5}: ErrInvalidOperation,
+	{"slashing", 6}: ErrInvalidOperation,
+	{"slashing", 7}: ErrInvalidOperation,
+	{"slashing", 8}: ErrNotFound,
+}
+
+// ABCITranslator translates ABCI errors to rosetta errors, it contains
+// the standard cosmos-sdk errors and can be extended by clients.
+type ABCITranslator struct {
+	mu     sync.RWMutex
+	errors map[abciKey]*Error
+}
+
+// NewABCITranslator returns a translator containing the standard cosmos-sdk errors
+func NewABCITranslator() *ABCITranslator {
+	t := &ABCITranslator{errors: make(map[abciKey]*Error, len(defaultABCIErrors))}
+	for k, v := range defaultABCIErrors {
+		t.errors[k] = v
+	}
+	return t
+}
+
+// Register makes the ABCI error identified by codespace and code translate to err,
+// existing translations are replaced
+func (t *ABCITranslator) Register(codespace string, code uint32, err *Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors[abciKey{codespace, code}] = err
+}
+
+// Translate returns the rosetta error of the ABCI error, the codespace, code and log
+// are kept in the details. Unknown errors are translated to ErrUnknown, a zero code means
+// success and translates to nil.
+func (t *ABCITranslator) Translate(codespace string, code uint32, log string) *Error {
+	if code == 0 {
+		return nil
+	}
+	rosErr, ok := t.lookup(codespace, code)
+	if !ok {
+		rosErr = ErrUnknown
+	}
+	return withDetails(rosErr, map[string]interface{}{
+		"info":           log,
+		DetailsCodespace: codespace,
+		DetailsCode:      code,
+	})
+}
+
+// lookup returns the rosetta error registered for codespace and code, if any
+func (t *ABCITranslator) lookup(codespace string, code uint32) (*Error, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rosErr, ok := t.errors[abciKey{codespace, code}]
+	return rosErr, ok
+}
+
+var abciTranslator = NewABCITranslator()
+
+// RegisterABCIError extends the translations of FromABCIError
+func RegisterABCIError(codespace string, code uint32, err *Error) {
+	abciTranslator.Register(codespace, code, err)
+}
+
+// FromABCIError converts an ABCI error, such as the one of a failed PostTx, to rosetta error
+func FromABCIError(codespace string, code uint32, log string) *Error {
+	return abciTranslator.Translate(codespace, code, log)
+}
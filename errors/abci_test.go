@@ -0,0 +1,82 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+import "testing"
+
+func TestABCITranslator(t *testing.T) {
+	custom := newError(9500, "custom module error", false, "", 0)
+
+	tests := []struct {
+		name      string
+		codespace string
+		code      uint32
+		want      *Error
+	}{
+		{"success", SDKCodespace, 0, nil},
+		{"insufficient funds", SDKCodespace, 5, ErrInsufficientFunds},
+		{"sequence mismatch", SDKCodespace, 32, ErrSequenceMismatch},
+		{"out of gas", SDKCodespace, 11, ErrOutOfGas},
+		{"bank send disabled", "bank", 5, ErrSendDisabled},
+		{"staking validator not found", "staking", 3, ErrValidatorNotFound},
+		{"internal error", UndefinedCodespace, 1, ErrInternal},
+		{"panic", UndefinedCodespace, 111222, ErrInternal},
+		{"no sdk error with code 1", SDKCodespace, 1, ErrUnknown},
+		{"distribution withdraw address disabled", "distribution", 8, ErrPermissionDenied},
+		{"distribution no delegation", "distribution", 13, ErrNotFound},
+		{"gov unknown proposal", "gov", 2, ErrNotFound},
+		{"gov inactive proposal", "gov", 3, ErrInvalidOperation},
+		{"slashing validator jailed", "slashing", 4, ErrValidatorJailed},
+		{"slashing validator not jailed", "slashing", 5, ErrInvalidOperation},
+		{"same code in another codespace", "bank", 32, ErrUnknown},
+		{"unknown codespace", "wasm", 5, ErrUnknown},
+		{"registered by the client", "custom", 1, custom},
+		{"registration replaces the default", SDKCodespace, 4, custom},
+	}
+
+	tr := NewABCITranslator()
+	tr.Register("custom", 1, custom)
+	tr.Register(SDKCodespace, 4, custom)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tr.Translate(tt.codespace, tt.code, "raw log")
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("expected no error, got %v", got)
+				}
+				return
+			}
+			if got == nil || got.rosErr.Code != tt.want.rosErr.Code {
+				t.Fatalf("expected error %d (%s), got %v", tt.want.rosErr.Code, tt.want.rosErr.Message, got)
+			}
+			details := got.rosErr.Details
+			if details["info"] != "raw log" || details[DetailsCodespace] != tt.codespace || details[DetailsCode] != tt.code {
+				t.Errorf("unexpected details %v", details)
+			}
+		})
+	}
+}
+
+func TestABCITranslatorsAreIndependent(t *testing.T) {
+	tr := NewABCITranslator()
+	tr.Register(SDKCodespace, 5, ErrInternal)
+	if got := NewABCITranslator().Translate(SDKCodespace, 5, ""); got.rosErr.Code != ErrInsufficientFunds.rosErr.Code {
+		t.Fatalf("registration leaked to another translator: got %s", got.rosErr.Message)
+	}
+}
@@ -0,0 +1,166 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+// catalog.go exports the errors of a registry in machine readable formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// CatalogFormat defines the format of an error catalog
+type CatalogFormat string
+
+const (
+	// CatalogJSON exports the catalog as a JSON array of rosetta errors with their HTTP status
+	CatalogJSON CatalogFormat = "json"
+	// CatalogMarkdown exports the catalog as a markdown table
+	CatalogMarkdown CatalogFormat = "markdown"
+	// CatalogOpenAPI exports the catalog as an OpenAPI components fragment,
+	// containing an example for each error
+	CatalogOpenAPI CatalogFormat = "openapi"
+)
+
+// CatalogFormats lists the supported catalog formats
+var CatalogFormats = []CatalogFormat{CatalogJSON, CatalogMarkdown, CatalogOpenAPI}
+
+// Catalog returns the registered errors sorted by code, it is
+// the content exported by WriteCatalog
+func (r *Registry) Catalog() []*types.Error {
+	return r.List()
+}
+
+// WriteCatalog writes the registered errors sorted by code in the given format,
+// the output is deterministic so that catalogs of different releases can be diffed
+func (r *Registry) WriteCatalog(w io.Writer, format CatalogFormat) error {
+	errs := r.Catalog()
+	switch format {
+	case CatalogJSON:
+		return writeJSONCatalog(w, errs, r.HTTPStatus)
+	case CatalogMarkdown:
+		return writeMarkdownCatalog(w, errs, r.HTTPStatus)
+	case CatalogOpenAPI:
+		return writeOpenAPICatalog(w, errs)
+	default:
+		return fmt.Errorf("unsupported catalog format %q", format)
+	}
+}
+
+// ReadCatalog reads a catalog previously exported in JSON format, the HTTP statuses are ignored
+func ReadCatalog(r io.Reader) ([]*types.Error, error) {
+	var errs []*types.Error
+	if err := json.NewDecoder(r).Decode(&errs); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	return errs, nil
+}
+
+// ReusedCodes returns the codes of previous whose message differs in current,
+// which means the code was reused for a different error. Removed codes are not reported.
+func ReusedCodes(previous, current []*types.Error) []int32 {
+	messages := make(map[int32]string, len(current))
+	for _, e := range current {
+		messages[e.Code] = e.Message
+	}
+	var reused []int32
+	for _, e := range previous {
+		if msg, ok := messages[e.Code]; ok && msg != e.Message {
+			reused = append(reused, e.Code)
+		}
+	}
+	sort.Slice(reused, func(i, j int) bool { return reused[i] < reused[j] })
+	return reused
+}
+
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// catalogError is a rosetta error of the JSON catalog
+type catalogError struct {
+	*types.Error
+	HTTPStatus int `json:"http_status"`
+}
+
+func writeJSONCatalog(w io.Writer, errs []*types.Error, httpStatus func(code int32) int) error {
+	catalog := make([]catalogError, len(errs))
+	for i, e := range errs {
+		catalog[i] = catalogError{Error: e, HTTPStatus: httpStatus(e.Code)}
+	}
+	return writeIndentedJSON(w, catalog)
+}
+
+func writeMarkdownCatalog(w io.Writer, errs []*types.Error, httpStatus func(code int32) int) error {
+	var b strings.Builder
+	b.WriteString("| Code | Message | Retriable | HTTP Status | Description |\n")
+	b.WriteString("|------|---------|-----------|-------------|-------------|\n")
+	for _, e := range errs {
+		_, _ = fmt.Fprintf(&b, "| %d | %s | %t | %d | %s |\n", e.Code, escapeMarkdown(e.Message), e.Retriable, httpStatus(e.Code), escapeMarkdown(description(e)))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// openAPIExample is an OpenAPI example object
+type openAPIExample struct {
+	Summary     string       `json:"summary"`
+	Description string       `json:"description,omitempty"`
+	Value       *types.Error `json:"value"`
+}
+
+// openAPIExampleKey returns the key of the error example, codes are zero padded to the digits
+// of the largest int32 so that the keys, which JSON objects sort as strings, are ordered by code
+func openAPIExampleKey(code int32) string {
+	return fmt.Sprintf("Error%010d", code)
+}
+
+func writeOpenAPICatalog(w io.Writer, errs []*types.Error) error {
+	examples := make(map[string]openAPIExample, len(errs))
+	for _, e := range errs {
+		examples[openAPIExampleKey(e.Code)] = openAPIExample{
+			Summary:     e.Message,
+			Description: description(e),
+			Value:       e,
+		}
+	}
+	return writeIndentedJSON(w, map[string]interface{}{
+		"components": map[string]interface{}{
+			"examples": examples,
+		},
+	})
+}
+
+func description(e *types.Error) string {
+	if e.Description == nil {
+		return ""
+	}
+	return *e.Description
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,132 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+func TestWriteCatalog(t *testing.T) {
+	r := newEmptyRegistry()
+	for _, e := range []*Error{
+		newError(2, "second", true, "pipe | and\nnewline", http.StatusConflict),
+		newError(1, "first", false, "first error", http.StatusBadRequest),
+	} {
+		if err := r.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format CatalogFormat
+		want   []string
+	}{
+		{CatalogJSON, []string{`"code": 1`, `"message": "first"`, `"retriable": true`, `"http_status": 400`, `"http_status": 409`}},
+		{CatalogMarkdown, []string{
+			"| Code | Message | Retriable | HTTP Status | Description |\n",
+			"| 1 | first | false | 400 | first error |\n| 2 | second | true | 409 | pipe \\| and newline |\n",
+		}},
+		{CatalogOpenAPI, []string{`"Error0000000001"`, `"Error0000000002"`, `"summary": "second"`}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var first, second bytes.Buffer
+			if err := r.WriteCatalog(&first, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.WriteCatalog(&second, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if first.String() != second.String() {
+				t.Fatal("catalog output is not deterministic")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(first.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, first.String())
+				}
+			}
+		})
+	}
+
+	if err := r.WriteCatalog(new(bytes.Buffer), "yaml"); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+}
+
+func TestWriteOpenAPICatalogOrder(t *testing.T) {
+	r := newEmptyRegistry()
+	codes := []int32{1000, 2, 100, 1}
+	for _, code := range codes {
+		if err := r.Add(newError(code, "error", false, "", http.StatusInternalServerError)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var b bytes.Buffer
+	if err := r.WriteCatalog(&b, CatalogOpenAPI); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	last := -1
+	for _, key := range []string{"Error0000000001", "Error0000000002", "Error0000000100", "Error0000001000"} {
+		i := strings.Index(out, `"`+key+`"`)
+		if i < 0 {
+			t.Fatalf("missing example %s in:\n%s", key, out)
+		}
+		if i < last {
+			t.Fatalf("example %s is not ordered by code in:\n%s", key, out)
+		}
+		last = i
+	}
+}
+
+func TestReadCatalog(t *testing.T) {
+	r := NewRegistry()
+	var b bytes.Buffer
+	if err := r.WriteCatalog(&b, CatalogJSON); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadCatalog(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(r.Catalog())
+	got, _ := json.Marshal(read)
+	if !bytes.Equal(want, got) {
+		t.Fatalf("catalog changed after a write and read:\nwant %s\ngot  %s", want, got)
+	}
+
+	if _, err := ReadCatalog(strings.NewReader("| Code |")); err == nil {
+		t.Fatal("expected invalid catalog error")
+	}
+}
+
+func TestReusedCodes(t *testing.T) {
+	previous := []*types.Error{{Code: 1, Message: "a"}, {Code: 2, Message: "b"}, {Code: 3, Message: "c"}, {Code: 4, Message: "d"}}
+	current := []*types.Error{{Code: 4, Message: "D"}, {Code: 1, Message: "a"}, {Code: 2, Message: "renamed"}, {Code: 5, Message: "new"}}
+	if got, want := ReusedCodes(previous, current), []int32{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected reused codes %v, got %v", want, got)
+	}
+}
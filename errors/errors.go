@@ -22,37 +22,78 @@ package errors
 // plus some extra utilities to parse those errors
 
 import (
+	stderrors "errors"
 	"fmt"
-
-	grpccodes "google.golang.org/grpc/codes"
-	grpcstatus "google.golang.org/grpc/status"
+	"net/http"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
-// ListErrors lists all the registered errors
+// ListErrors lists all the errors of the default registry
 func ListErrors() []*types.Error {
-	return registry.list()
+	return registry.List()
 }
 
-// SealAndListErrors seals the registry and lists its errors
+// SealAndListErrors seals the default registry and lists its errors
 func SealAndListErrors() []*types.Error {
-	registry.seal()
-	return registry.list()
+	_ = registry.Seal()
+	return registry.List()
 }
 
+// DetailsInfo is the details key containing the context message added by WrapError
+const DetailsInfo = "info"
+
+// Common details keys
+const (
+	// DetailsField is the request field which caused the error
+	DetailsField = "field"
+	// DetailsHeight is the block height the error relates to
+	DetailsHeight = "height"
+	// DetailsNode is the upstream node which returned the error
+	DetailsNode = "node"
+	// DetailsOperationIndex is the index of the request operation which caused the error
+	DetailsOperationIndex = "operation_index"
+	// DetailsOperationIndexes are the indexes of the request operations which caused the error together
+	DetailsOperationIndexes = "operation_indexes"
+)
+
+// DetailsCauses is the details key containing the cause chain, populated only by ToRosettaDebug
+const DetailsCauses = "causes"
+
 // Error defines an error that can be converted to a Rosetta API error.
 type Error struct {
 	rosErr *types.Error
+	// cause is the underlying error, if any
+	cause error
+	// httpStatus is the HTTP status category of the error
+	httpStatus int
+}
+
+// HTTPStatus returns the HTTP status of the error, errors without one
+// are considered internal server errors
+func (e *Error) HTTPStatus() int {
+	if e == nil || e.httpStatus == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.httpStatus
 }
 
 func (e *Error) Error() string {
 	if e.rosErr == nil {
 		return ErrUnknown.Error()
 	}
+	if e.cause != nil {
+		return fmt.Sprintf("rosetta: (%d) %s: %s", e.rosErr.Code, e.rosErr.Message, e.cause)
+	}
 	return fmt.Sprintf("rosetta: (%d) %s", e.rosErr.Code, e.rosErr.Message)
 }
 
+// Unwrap returns the cause of the error, which allows errors.Is and errors.As
+// to inspect the error chain
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
 // Is implements errors.Is for *Error, two errors are considered equal
 // if their error codes are identical
 func (e *Error) Is(err error) bool {
@@ -73,98 +114,176 @@ func (e *Error) Is(err error) bool {
 	return rosErr.rosErr.Code == e.rosErr.Code
 }
 
-// WrapError wraps the rosetta error with additional context
+// WithDetail returns a copy of the error with the given detail added,
+// existing details and cause are preserved
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	return e.WithDetails(map[string]interface{}{key: value})
+}
+
+// WithDetails returns a copy of the error with the given details added,
+// existing details and cause are preserved
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	merged := make(map[string]interface{}, len(e.rosErr.Details)+len(details))
+	for k, v := range e.rosErr.Details {
+		merged[k] = v
+	}
+	for k, v := range details {
+		merged[k] = v
+	}
+	cpy := withDetails(e, merged)
+	cpy.cause = e.cause
+	return cpy
+}
+
+// WrapError wraps the rosetta error with additional context,
+// existing details and cause are preserved
 func WrapError(err *Error, msg string) *Error {
-	return &Error{rosErr: &types.Error{
-		Code:        err.rosErr.Code,
-		Message:     err.rosErr.Message,
-		Description: err.rosErr.Description,
-		Retriable:   err.rosErr.Retriable,
-		Details: map[string]interface{}{
-			"info": msg,
+	return err.WithDetail(DetailsInfo, msg)
+}
+
+// Wrap returns a copy of the rosetta error caused by cause, the cause
+// is reachable via errors.Is and errors.As but it is not part of the
+// rosetta error details unless converted with ToRosettaDebug
+func Wrap(err *Error, cause error) *Error {
+	cpy := err.WithDetails(nil)
+	cpy.cause = cause
+	return cpy
+}
+
+// withDetails returns a copy of err with the given details
+func withDetails(err *Error, details map[string]interface{}) *Error {
+	return &Error{
+		rosErr: &types.Error{
+			Code:        err.rosErr.Code,
+			Message:     err.rosErr.Message,
+			Description: err.rosErr.Description,
+			Retriable:   err.rosErr.Retriable,
+			Details:     details,
 		},
-	}}
+		httpStatus: err.httpStatus,
+	}
 }
 
 // ToRosetta attempts to converting an error into a rosetta
-// error, if the error cannot be converted it will be parsed as unknown
+// error, if the error cannot be converted it will be parsed as unknown.
+// The cause chain is not part of the returned error.
 func ToRosetta(err error) *types.Error {
 	if err == nil {
 		return nil
 	}
-	rosErr, ok := err.(*Error)
-	if !ok {
+	var rosErr *Error
+	if !stderrors.As(err, &rosErr) {
 		return ToRosetta(WrapError(ErrUnknown, err.Error()))
 	}
 	return rosErr.rosErr
 }
 
-// FromGRPCToRosettaError converts a gRPC error to rosetta error
-func FromGRPCToRosettaError(err error) *Error {
-	status, ok := grpcstatus.FromError(err)
-	if !ok {
-		return WrapError(ErrUnknown, err.Error())
+// ToRosettaDebug converts the error as ToRosetta does, adding the
+// cause chain messages to the details. It must be used only in debug mode
+// as causes can leak internal information.
+func ToRosettaDebug(err error) *types.Error {
+	if err == nil {
+		return nil
 	}
-	switch status.Code() {
-	case grpccodes.NotFound:
-		return WrapError(ErrNotFound, status.Message())
-	case grpccodes.FailedPrecondition:
-		return WrapError(ErrBadArgument, status.Message())
-	case grpccodes.InvalidArgument:
-		return WrapError(ErrBadArgument, status.Message())
-	case grpccodes.Internal:
-		return WrapError(ErrInternal, status.Message())
-	default:
-		return WrapError(ErrUnknown, status.Message())
+	var causes []string
+	for cause := stderrors.Unwrap(err); cause != nil; cause = stderrors.Unwrap(cause) {
+		causes = append(causes, cause.Error())
 	}
+	if len(causes) == 0 {
+		return ToRosetta(err)
+	}
+	var rosErr *Error
+	if !stderrors.As(err, &rosErr) {
+		rosErr = WrapError(ErrUnknown, err.Error())
+	}
+	return rosErr.WithDetail(DetailsCauses, causes).rosErr
 }
 
+// RegisterError builds an error and adds it to the default registry,
+// registration failures such as duplicate codes are reported when the registry is sealed.
+// Clients running multiple networks should use their own Registry instead.
 func RegisterError(code int32, message string, retryable bool, description string) *Error {
-	e := &Error{rosErr: &types.Error{
-		Code:        code,
-		Message:     message,
-		Description: &description,
-		Retriable:   retryable,
-		Details:     nil,
-	}}
-	registry.add(e)
+	return RegisterErrorWithHTTPStatus(code, message, retryable, description, http.StatusInternalServerError)
+}
+
+// RegisterErrorWithHTTPStatus is like RegisterError, the HTTP status is used
+// for the responses containing the error when HTTP statuses are enabled in the server
+func RegisterErrorWithHTTPStatus(code int32, message string, retryable bool, description string, httpStatus int) *Error {
+	e := newError(code, message, retryable, description, httpStatus)
+	registry.mustAdd(e)
 	return e
 }
 
-// Default error list
+func newError(code int32, message string, retryable bool, description string, httpStatus int) *Error {
+	return &Error{
+		rosErr: &types.Error{
+			Code:        code,
+			Message:     message,
+			Description: &description,
+			Retriable:   retryable,
+			Details:     nil,
+		},
+		httpStatus: httpStatus,
+	}
+}
+
+// statusClientClosedRequest is the non standard HTTP status used when the client cancels the request
+const statusClientClosedRequest = 499
+
+// Default error list, the default registry is copied by NewRegistry
+// so these errors are part of every registry
 var (
 	// ErrUnknown defines an unknown error, if this is returned it means
 	// the library is ignoring an error
-	ErrUnknown = RegisterError(0, "unknown", false, "unknown error")
+	ErrUnknown = RegisterErrorWithHTTPStatus(0, "unknown", false, "unknown error", http.StatusInternalServerError)
 	// ErrOffline is returned when there is an attempt to query an endpoint in offline mode
-	ErrOffline = RegisterError(1, "cannot query endpoint in offline mode", false, "returned when querying an online endpoint in offline mode")
+	ErrOffline = RegisterErrorWithHTTPStatus(1, "cannot query endpoint in offline mode", false, "returned when querying an online endpoint in offline mode", http.StatusNotImplemented)
 	// ErrNetworkNotSupported is returned when there is an attempt to query a network which is not supported
-	ErrNetworkNotSupported = RegisterError(2, "network is not supported", false, "returned when querying a non supported network")
+	ErrNetworkNotSupported = RegisterErrorWithHTTPStatus(2, "network is not supported", false, "returned when querying a non supported network", http.StatusBadRequest)
 	// ErrCodec is returned when there's an error while marshalling or unmarshalling data
-	ErrCodec = RegisterError(3, "encode/decode error", true, "returned when there are errors encoding or decoding information to and from the node")
+	ErrCodec = RegisterErrorWithHTTPStatus(3, "encode/decode error", true, "returned when there are errors encoding or decoding information to and from the node", http.StatusInternalServerError)
 	// ErrInvalidOperation is returned when the operation supplied to rosetta is not a valid one
-	ErrInvalidOperation = RegisterError(4, "invalid operation", false, "returned when the operation is not valid")
+	ErrInvalidOperation = RegisterErrorWithHTTPStatus(4, "invalid operation", false, "returned when the operation is not valid", http.StatusBadRequest)
 	// ErrInvalidTransaction is returned when the provided hex bytes of a TX are not valid
-	ErrInvalidTransaction = RegisterError(5, "invalid transaction", false, "returned when the transaction is invalid")
+	ErrInvalidTransaction = RegisterErrorWithHTTPStatus(5, "invalid transaction", false, "returned when the transaction is invalid", http.StatusBadRequest)
 	// ErrInvalidAddress is returned when the byte of the address are bad
-	ErrInvalidAddress = RegisterError(7, "invalid address", false, "returned when the address is malformed")
+	ErrInvalidAddress = RegisterErrorWithHTTPStatus(7, "invalid address", false, "returned when the address is malformed", http.StatusBadRequest)
 	// ErrInvalidPubkey is returned when the public key is invalid
-	ErrInvalidPubkey = RegisterError(8, "invalid pubkey", false, "returned when the public key is invalid")
+	ErrInvalidPubkey = RegisterErrorWithHTTPStatus(8, "invalid pubkey", false, "returned when the public key is invalid", http.StatusBadRequest)
 	// ErrInterpreting is returned when there are errors interpreting the data from the node, most likely related to breaking changes, version incompatibilities
-	ErrInterpreting = RegisterError(9, "error interpreting data from node", false, "returned when there are issues interpreting requests or response from node")
-	ErrInvalidMemo  = RegisterError(11, "invalid memo", false, "returned when the memo is invalid")
+	ErrInterpreting = RegisterErrorWithHTTPStatus(9, "error interpreting data from node", false, "returned when there are issues interpreting requests or response from node", http.StatusInternalServerError)
+	ErrInvalidMemo  = RegisterErrorWithHTTPStatus(11, "invalid memo", false, "returned when the memo is invalid", http.StatusBadRequest)
 	// ErrBadArgument is returned when the request is malformed
-	ErrBadArgument = RegisterError(400, "bad argument", false, "request is malformed")
+	ErrBadArgument = RegisterErrorWithHTTPStatus(400, "bad argument", false, "request is malformed", http.StatusBadRequest)
 	// ErrNotFound is returned when the required object was not found
 	// retry is set to true because something that is not found now
 	// might be found later, example: a TX
-	ErrNotFound = RegisterError(404, "not found", true, "returned when the node does not find what the client is asking for")
+	ErrNotFound = RegisterErrorWithHTTPStatus(404, "not found", true, "returned when the node does not find what the client is asking for", http.StatusNotFound)
 	// ErrInternal is returned when the node is experiencing internal errors
-	ErrInternal = RegisterError(500, "internal error", false, "returned when the node experiences internal errors")
+	ErrInternal = RegisterErrorWithHTTPStatus(500, "internal error", false, "returned when the node experiences internal errors", http.StatusInternalServerError)
 	// ErrBadGateway is returned when there are problems interacting with the nodes
-	ErrBadGateway = RegisterError(502, "bad gateway", true, "return when the node is unreachable")
+	ErrBadGateway = RegisterErrorWithHTTPStatus(502, "bad gateway", true, "return when the node is unreachable", http.StatusServiceUnavailable)
 	// ErrNotImplemented is returned when a method is not implemented yet
-	ErrNotImplemented = RegisterError(14, "not implemented", false, "returned when querying an endpoint which is not implemented")
+	ErrNotImplemented = RegisterErrorWithHTTPStatus(14, "not implemented", false, "returned when querying an endpoint which is not implemented", http.StatusNotImplemented)
 	// ErrUnsupportedCurve is returned when the curve specified is not supported
-	ErrUnsupportedCurve = RegisterError(15, "unsupported curve, expected secp256k1", false, "returned when using an unsupported crypto curve")
+	ErrUnsupportedCurve = RegisterErrorWithHTTPStatus(15, "unsupported curve, expected secp256k1", false, "returned when using an unsupported crypto curve", http.StatusBadRequest)
+	// ErrAborted is returned when the node aborted the operation, usually due to concurrency issues
+	ErrAborted = RegisterErrorWithHTTPStatus(16, "aborted", true, "returned when the node aborts the operation", http.StatusConflict)
+	// ErrUnauthenticated is returned when the node requires authentication
+	ErrUnauthenticated = RegisterErrorWithHTTPStatus(401, "unauthenticated", false, "returned when the request to the node lacks valid credentials", http.StatusUnauthorized)
+	// ErrPermissionDenied is returned when the node refuses to perform the operation
+	ErrPermissionDenied = RegisterErrorWithHTTPStatus(403, "permission denied", false, "returned when the node denies permission to perform the operation", http.StatusForbidden)
+	// ErrAlreadyExists is returned when the object the client attempts to create already exists
+	ErrAlreadyExists = RegisterErrorWithHTTPStatus(409, "already exists", false, "returned when the object already exists", http.StatusConflict)
+	// ErrOutOfRange is returned when the request goes beyond the valid range, for example
+	// a height greater than the latest one, it might succeed later
+	ErrOutOfRange = RegisterErrorWithHTTPStatus(416, "out of range", true, "returned when the request is out of the valid range", http.StatusNotFound)
+	// ErrResourceExhausted is returned when the node is rate limiting or out of resources
+	ErrResourceExhausted = RegisterErrorWithHTTPStatus(429, "resource exhausted", true, "returned when the node resources are exhausted", http.StatusTooManyRequests)
+	// ErrCanceled is returned when the operation was canceled
+	ErrCanceled = RegisterErrorWithHTTPStatus(499, "canceled", true, "returned when the operation is canceled", statusClientClosedRequest)
+	// ErrMaintenance is returned by every endpoint while the gateway is in maintenance mode
+	ErrMaintenance = RegisterErrorWithHTTPStatus(503, "service in maintenance", true, "returned when the gateway is in maintenance mode", http.StatusServiceUnavailable)
+	// ErrTimeout is returned when the operation does not complete in time
+	ErrTimeout = RegisterErrorWithHTTPStatus(504, "timeout", true, "returned when the operation times out", http.StatusGatewayTimeout)
 )
@@ -0,0 +1,137 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// causeError is a typed cause used to check errors.As through wrapped errors
+type causeError struct{ msg string }
+
+func (e *causeError) Error() string { return e.msg }
+
+func TestWrap(t *testing.T) {
+	cause := &causeError{msg: "node unreachable"}
+	err := Wrap(ErrBadGateway, fmt.Errorf("querying balances: %w", cause))
+	// wrapping the rosetta error again keeps its cause chain
+	wrapped := fmt.Errorf("handler: %w", WrapError(err, "balances"))
+
+	if !stderrors.Is(wrapped, ErrBadGateway) {
+		t.Error("expected errors.Is to match the rosetta error")
+	}
+	if stderrors.Is(wrapped, ErrInternal) {
+		t.Error("expected errors.Is not to match another rosetta error")
+	}
+	var gotCause *causeError
+	if !stderrors.As(wrapped, &gotCause) || gotCause != cause {
+		t.Error("expected errors.As to reach the cause")
+	}
+	var rosErr *Error
+	if !stderrors.As(wrapped, &rosErr) || rosErr.rosErr.Details[DetailsInfo] != "balances" {
+		t.Error("expected errors.As to reach the rosetta error")
+	}
+	if !strings.Contains(err.Error(), cause.msg) {
+		t.Errorf("expected the message to contain the cause, got %q", err.Error())
+	}
+
+	// the registered error is not modified
+	if ErrBadGateway.cause != nil || ErrBadGateway.rosErr.Details != nil {
+		t.Error("expected Wrap to return a copy")
+	}
+	if Wrap(ErrBadGateway, io.EOF).HTTPStatus() != ErrBadGateway.HTTPStatus() {
+		t.Error("expected the HTTP status to be kept")
+	}
+}
+
+func TestWithDetails(t *testing.T) {
+	base := Wrap(ErrBadArgument, io.EOF).WithDetail("first", 1)
+	err := base.WithDetails(map[string]interface{}{"first": 2, "second": "b"})
+
+	want := map[string]interface{}{"first": 2, "second": "b"}
+	if !reflect.DeepEqual(err.rosErr.Details, want) {
+		t.Errorf("expected details %v, got %v", want, err.rosErr.Details)
+	}
+	if base.rosErr.Details["first"] != 1 {
+		t.Error("expected the details of the original error to be kept")
+	}
+	if !stderrors.Is(err, io.EOF) {
+		t.Error("expected the cause to be kept")
+	}
+	if got := WrapError(err, "info").rosErr.Details; got[DetailsInfo] != "info" || got["second"] != "b" {
+		t.Errorf("expected WrapError to merge the details, got %v", got)
+	}
+}
+
+func TestToRosettaDebug(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   int32
+		wantCauses []string
+	}{
+		{name: "nil", err: nil},
+		{name: "no cause", err: WrapError(ErrNotFound, "tx"), wantCode: ErrNotFound.rosErr.Code},
+		{
+			name:       "cause chain",
+			err:        Wrap(ErrBadGateway, fmt.Errorf("dial: %w", io.EOF)),
+			wantCode:   ErrBadGateway.rosErr.Code,
+			wantCauses: []string{"dial: EOF", "EOF"},
+		},
+		{
+			name:       "wrapped rosetta error",
+			err:        fmt.Errorf("handler: %w", ErrTimeout),
+			wantCode:   ErrTimeout.rosErr.Code,
+			wantCauses: []string{ErrTimeout.Error()},
+		},
+		{
+			name:       "not a rosetta error",
+			err:        fmt.Errorf("handler: %w", io.EOF),
+			wantCode:   ErrUnknown.rosErr.Code,
+			wantCauses: []string{"EOF"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToRosettaDebug(tt.err)
+			if tt.err == nil {
+				if got != nil {
+					t.Fatalf("expected nil, got %+v", got)
+				}
+				return
+			}
+			if got.Code != tt.wantCode {
+				t.Fatalf("expected code %d, got %d", tt.wantCode, got.Code)
+			}
+			causes, _ := got.Details[DetailsCauses].([]string)
+			if !reflect.DeepEqual(causes, tt.wantCauses) {
+				t.Fatalf("expected causes %q, got %q", tt.wantCauses, causes)
+			}
+			// the causes are only exposed in debug mode
+			if _, ok := ToRosetta(tt.err).Details[DetailsCauses]; ok {
+				t.Fatal("expected ToRosetta not to expose the causes")
+			}
+		})
+	}
+}
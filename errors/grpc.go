@@ -0,0 +1,181 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+// grpc.go contains the conversion of gRPC errors to rosetta errors
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Details keys populated when converting gRPC errors
+const (
+	DetailsGRPCCode  = "grpc_code"
+	DetailsErrorInfo = "error_info"
+	DetailsDebugInfo = "debug_info"
+	DetailsCodespace = "codespace"
+	DetailsCode      = "code"
+)
+
+// grpcCodesToRosetta maps every gRPC code to its rosetta error
+var grpcCodesToRosetta = map[grpccodes.Code]*Error{
+	grpccodes.Canceled:           ErrCanceled,
+	grpccodes.Unknown:            ErrUnknown,
+	grpccodes.InvalidArgument:    ErrBadArgument,
+	grpccodes.DeadlineExceeded:   ErrTimeout,
+	grpccodes.NotFound:           ErrNotFound,
+	grpccodes.AlreadyExists:      ErrAlreadyExists,
+	grpccodes.PermissionDenied:   ErrPermissionDenied,
+	grpccodes.ResourceExhausted:  ErrResourceExhausted,
+	grpccodes.FailedPrecondition: ErrBadArgument,
+	grpccodes.Aborted:            ErrAborted,
+	grpccodes.OutOfRange:         ErrOutOfRange,
+	grpccodes.Unimplemented:      ErrNotImplemented,
+	grpccodes.Internal:           ErrInternal,
+	grpccodes.Unavailable:        ErrBadGateway,
+	grpccodes.DataLoss:           ErrInternal,
+	grpccodes.Unauthenticated:    ErrUnauthenticated,
+}
+
+// sdkCodespaceRegex matches the codespace and code cosmos-sdk errors carry in their message
+var sdkCodespaceRegex = regexp.MustCompile(`codespace[:=\s]+(\w+)[,\s]+code[:=\s]+(\d+)`)
+
+// GRPCConverter converts gRPC errors to rosetta errors, the default mapping
+// of gRPC codes can be overridden per cosmos-sdk codespace. When the error carries
+// a cosmos-sdk codespace and code known by the ABCI translator, the ABCI error is used.
+type GRPCConverter struct {
+	mu        sync.RWMutex
+	overrides map[string]map[grpccodes.Code]*Error
+	abci      *ABCITranslator
+}
+
+// NewGRPCConverter returns a converter using the default gRPC codes mapping
+// and the ABCI translations of FromABCIError
+func NewGRPCConverter() *GRPCConverter {
+	return &GRPCConverter{
+		overrides: make(map[string]map[grpccodes.Code]*Error),
+		abci:      abciTranslator,
+	}
+}
+
+// Override makes errors with the given codespace and gRPC code convert to err
+func (c *GRPCConverter) Override(codespace string, code grpccodes.Code, err *Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	byCode, ok := c.overrides[codespace]
+	if !ok {
+		byCode = make(map[grpccodes.Code]*Error)
+		c.overrides[codespace] = byCode
+	}
+	byCode[code] = err
+}
+
+// Convert converts a gRPC error to a rosetta error, the original status details
+// are preserved in the rosetta error details
+func (c *GRPCConverter) Convert(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	status, ok := grpcstatus.FromError(err)
+	if !ok {
+		return Wrap(WrapError(ErrUnknown, err.Error()), err)
+	}
+
+	details := map[string]interface{}{
+		"info":          status.Message(),
+		DetailsGRPCCode: status.Code().String(),
+	}
+	var (
+		codespace string
+		abciCode  uint32
+	)
+	for _, d := range status.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			details[DetailsErrorInfo] = map[string]interface{}{
+				"reason":   d.GetReason(),
+				"domain":   d.GetDomain(),
+				"metadata": d.GetMetadata(),
+			}
+			if cs, ok := d.GetMetadata()[DetailsCodespace]; ok {
+				codespace = cs
+				details[DetailsCodespace] = cs
+			}
+			if code, err := strconv.ParseUint(d.GetMetadata()[DetailsCode], 10, 32); err == nil {
+				abciCode = uint32(code)
+				details[DetailsCode] = abciCode
+			}
+		case *errdetails.DebugInfo:
+			details[DetailsDebugInfo] = map[string]interface{}{
+				"stack_entries": d.GetStackEntries(),
+				"detail":        d.GetDetail(),
+			}
+		}
+	}
+	if codespace == "" {
+		if match := sdkCodespaceRegex.FindStringSubmatch(status.Message()); match != nil {
+			codespace = match[1]
+			details[DetailsCodespace] = codespace
+			if code, err := strconv.ParseUint(match[2], 10, 32); err == nil {
+				abciCode = uint32(code)
+				details[DetailsCode] = abciCode
+			}
+		}
+	}
+
+	return Wrap(c.rosettaError(codespace, abciCode, status.Code()).WithDetails(details), err)
+}
+
+// rosettaError returns the rosetta error given the codespace, the ABCI code and gRPC code,
+// in order of precedence: codespace overrides, ABCI translations, gRPC code mapping
+func (c *GRPCConverter) rosettaError(codespace string, abciCode uint32, code grpccodes.Code) *Error {
+	c.mu.RLock()
+	override, ok := c.overrides[codespace][code]
+	c.mu.RUnlock()
+	if ok {
+		return override
+	}
+	if codespace != "" && abciCode != 0 {
+		if rosErr, ok := c.abci.lookup(codespace, abciCode); ok {
+			return rosErr
+		}
+	}
+	if rosErr, ok := grpcCodesToRosetta[code]; ok {
+		return rosErr
+	}
+	return ErrUnknown
+}
+
+var grpcConverter = NewGRPCConverter()
+
+// RegisterGRPCOverride overrides the conversion of FromGRPCToRosettaError for the given codespace and gRPC code
+func RegisterGRPCOverride(codespace string, code grpccodes.Code, err *Error) {
+	grpcConverter.Override(codespace, code, err)
+}
+
+// FromGRPCToRosettaError converts a gRPC error to rosetta error
+func FromGRPCToRosettaError(err error) *Error {
+	return grpcConverter.Convert(err)
+}
@@ -0,0 +1,122 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+func TestGRPCCodesAreMapped(t *testing.T) {
+	for code := grpccodes.Canceled; code <= grpccodes.Unauthenticated; code++ {
+		if _, ok := grpcCodesToRosetta[code]; !ok {
+			t.Errorf("gRPC code %s is not mapped", code)
+		}
+	}
+}
+
+func TestGRPCConverter(t *testing.T) {
+	overridden := newError(9400, "overridden", false, "", 0)
+	withInfo := func(code grpccodes.Code, msg string, metadata map[string]string) error {
+		st, err := grpcstatus.New(code, msg).WithDetails(&errdetails.ErrorInfo{Reason: "reason", Metadata: metadata})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Err()
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want *Error
+		// wantDetails are the expected rosetta error details, only the listed keys are checked
+		wantDetails map[string]interface{}
+	}{
+		{
+			name: "not a gRPC error",
+			err:  stderrors.New("boom"),
+			want: ErrUnknown,
+		},
+		{
+			name:        "gRPC code",
+			err:         grpcstatus.Error(grpccodes.NotFound, "no such block"),
+			want:        ErrNotFound,
+			wantDetails: map[string]interface{}{"info": "no such block", DetailsGRPCCode: "NotFound"},
+		},
+		{
+			name: "canceled",
+			err:  grpcstatus.Error(grpccodes.Canceled, "canceled"),
+			want: ErrCanceled,
+		},
+		{
+			name:        "codespace and code from the error info",
+			err:         withInfo(grpccodes.InvalidArgument, "failed", map[string]string{DetailsCodespace: SDKCodespace, DetailsCode: "5"}),
+			want:        ErrInsufficientFunds,
+			wantDetails: map[string]interface{}{DetailsCodespace: SDKCodespace, DetailsCode: uint32(5)},
+		},
+		{
+			name:        "codespace and code from the message",
+			err:         grpcstatus.Error(grpccodes.Unknown, "failed to execute message; codespace: sdk, code: 32"),
+			want:        ErrSequenceMismatch,
+			wantDetails: map[string]interface{}{DetailsCodespace: SDKCodespace, DetailsCode: uint32(32)},
+		},
+		{
+			name: "unknown ABCI code falls back to the gRPC code",
+			err:  grpcstatus.Error(grpccodes.Unavailable, "codespace: sdk, code: 999"),
+			want: ErrBadGateway,
+		},
+		{
+			name: "codespace override",
+			err:  grpcstatus.Error(grpccodes.InvalidArgument, "codespace: custom, code: 2"),
+			want: overridden,
+		},
+		{
+			name: "override is per codespace",
+			err:  grpcstatus.Error(grpccodes.InvalidArgument, "codespace: other, code: 2"),
+			want: ErrBadArgument,
+		},
+	}
+
+	c := NewGRPCConverter()
+	c.Override("custom", grpccodes.InvalidArgument, overridden)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Convert(tt.err)
+			if got.rosErr.Code != tt.want.rosErr.Code {
+				t.Fatalf("expected error %d (%s), got %d (%s)", tt.want.rosErr.Code, tt.want.rosErr.Message, got.rosErr.Code, got.rosErr.Message)
+			}
+			if !stderrors.Is(got, tt.err) {
+				t.Errorf("converted error does not wrap the original one")
+			}
+			for k, v := range tt.wantDetails {
+				if got.rosErr.Details[k] != v {
+					t.Errorf("details[%s]: expected %v, got %v", k, v, got.rosErr.Details[k])
+				}
+			}
+		})
+	}
+
+	if c.Convert(nil) != nil {
+		t.Fatal("expected nil error to convert to nil")
+	}
+}
@@ -20,47 +20,155 @@ package errors
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"sync"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
-type errorRegistry struct {
-	mu     *sync.RWMutex
+// Registry holds the errors a rosetta network can return, which are advertised
+// in /network/options. Errors can be registered until the registry is sealed.
+type Registry struct {
+	mu     sync.RWMutex
 	sealed bool
-	errors map[int32]*types.Error
+	errors map[int32]*Error
+	// errs collects registration failures that could not be returned
+	// to the caller, they are reported by Seal
+	errs []error
 }
 
-func (r errorRegistry) add(err *Error) {
+// NewRegistry returns a registry containing the default errors of the library
+// and the ones registered so far with the package level RegisterError,
+// clients can register their own errors on top of them.
+func NewRegistry() *Registry {
+	r := newEmptyRegistry()
+	for _, err := range registry.registered() {
+		if regErr := r.Add(err); regErr != nil {
+			panic(regErr)
+		}
+	}
+	return r
+}
+
+func newEmptyRegistry() *Registry {
+	return &Registry{errors: make(map[int32]*Error)}
+}
+
+// Register builds a new error and adds it to the registry
+func (r *Registry) Register(code int32, message string, retriable bool, description string) (*Error, error) {
+	return r.RegisterWithHTTPStatus(code, message, retriable, description, http.StatusInternalServerError)
+}
+
+// RegisterWithHTTPStatus is like Register, the HTTP status is used for the responses
+// containing the error when HTTP statuses are enabled in the server
+func (r *Registry) RegisterWithHTTPStatus(code int32, message string, retriable bool, description string, httpStatus int) (*Error, error) {
+	e := newError(code, message, retriable, description, httpStatus)
+	if err := r.Add(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Add adds an already built error to the registry, it fails if the registry is sealed
+// or if an error with the same code is already registered
+func (r *Registry) Add(err *Error) error {
+	if err == nil || err.rosErr == nil {
+		return fmt.Errorf("cannot register a nil error")
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.sealed {
-		_, _ = fmt.Fprintln(os.Stderr, "[ROSETTA] WARNING: attempts to register errors after seal will be ignored")
+		return fmt.Errorf("cannot register error %d: registry is sealed", err.rosErr.Code)
+	}
+	if registered, ok := r.errors[err.rosErr.Code]; ok {
+		return fmt.Errorf("cannot register error %d (%s): code already used by %s", err.rosErr.Code, err.rosErr.Message, registered.rosErr.Message)
 	}
-	if _, ok := r.errors[err.rosErr.Code]; ok {
-		_, _ = fmt.Fprintln(os.Stderr, "[ROSETTA] WARNING: attempts to register an already registered error will be ignored, code: ", err.rosErr.Code)
+	r.errors[err.rosErr.Code] = err
+	return nil
+}
+
+// Seal prevents further registrations, it returns the registration failures
+// that happened before sealing, if any. Sealing is idempotent.
+func (r *Registry) Seal() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sealed = true
+	if len(r.errs) == 0 {
+		return nil
 	}
-	r.errors[err.rosErr.Code] = err.rosErr
+	return fmt.Errorf("invalid error registry: %v", r.errs)
+}
+
+// Sealed reports if the registry is sealed
+func (r *Registry) Sealed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sealed
 }
 
-func (r errorRegistry) list() []*types.Error {
+// List returns the registered errors sorted by code
+func (r *Registry) List() []*types.Error {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	rosErrs := make([]*types.Error, 0, len(registry.errors))
+	rosErrs := make([]*types.Error, 0, len(r.errors))
 	for _, v := range r.errors {
-		rosErrs = append(rosErrs, v)
+		rosErrs = append(rosErrs, v.rosErr)
 	}
+	sort.Slice(rosErrs, func(i, j int) bool { return rosErrs[i].Code < rosErrs[j].Code })
 	return rosErrs
 }
 
-func (r errorRegistry) seal() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.sealed = true
+// registered returns the registered errors sorted by code
+func (r *Registry) registered() []*Error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	errs := make([]*Error, 0, len(r.errors))
+	for _, v := range r.errors {
+		errs = append(errs, v)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].rosErr.Code < errs[j].rosErr.Code })
+	return errs
+}
+
+// HTTPStatus returns the HTTP status of the error with the given code,
+// unknown codes are considered internal server errors
+func (r *Registry) HTTPStatus(code int32) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	err, ok := r.errors[code]
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	return err.HTTPStatus()
+}
+
+// FromRosetta builds an error from its rosetta representation, such as one read from
+// a recording, the HTTP status is the one of the registered error with the same code
+func (r *Registry) FromRosetta(rosErr *types.Error) *Error {
+	cpy := *rosErr
+	return &Error{rosErr: &cpy, httpStatus: r.HTTPStatus(rosErr.Code)}
 }
 
-var registry = errorRegistry{
-	mu:     new(sync.RWMutex),
-	errors: make(map[int32]*types.Error),
+// mustAdd adds the error and records the failure, if any, to be reported by Seal,
+// it is used by the package level RegisterError which cannot return errors
+func (r *Registry) mustAdd(err *Error) {
+	if r.Sealed() {
+		_, _ = fmt.Fprintln(os.Stderr, "[ROSETTA] WARNING: attempts to register errors after seal will be ignored, code: ", err.rosErr.Code)
+		return
+	}
+	if regErr := r.Add(err); regErr != nil {
+		r.mu.Lock()
+		r.errs = append(r.errs, regErr)
+		r.mu.Unlock()
+	}
 }
+
+// DefaultRegistry returns the process wide registry used by RegisterError,
+// it is used by networks whose settings do not provide a registry.
+func DefaultRegistry() *Registry {
+	return registry
+}
+
+var registry = newEmptyRegistry()
@@ -0,0 +1,141 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewRegistryContainsPackageErrors(t *testing.T) {
+	// the package errors are registered in a copy of the default registry so that the test can run multiple times
+	defaultRegistry := registry
+	registry = newEmptyRegistry()
+	for _, e := range defaultRegistry.registered() {
+		registry.mustAdd(e)
+	}
+	defer func() { registry = defaultRegistry }()
+
+	pkgErr := RegisterErrorWithHTTPStatus(9100, "package registered", false, "registered with RegisterError", http.StatusBadRequest)
+
+	r := NewRegistry()
+	codes := make(map[int32]bool)
+	for _, e := range r.List() {
+		codes[e.Code] = true
+	}
+	for _, e := range []*Error{ErrUnknown, ErrOffline, ErrTimeout, pkgErr} {
+		if !codes[e.rosErr.Code] {
+			t.Errorf("error %d (%s) missing from the new registry", e.rosErr.Code, e.rosErr.Message)
+		}
+	}
+	if got := r.HTTPStatus(pkgErr.rosErr.Code); got != http.StatusBadRequest {
+		t.Errorf("expected HTTP status %d, got %d", http.StatusBadRequest, got)
+	}
+
+	// errors registered afterwards do not leak into the registries already built
+	late := RegisterError(9101, "late", false, "registered after NewRegistry")
+	for _, e := range r.List() {
+		if e.Code == late.rosErr.Code {
+			t.Fatalf("error %d registered after NewRegistry leaked into it", e.Code)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(r *Registry) error
+		wantErr string
+	}{
+		{
+			name: "register",
+			setup: func(r *Registry) error {
+				_, err := r.Register(9200, "client error", true, "")
+				return err
+			},
+		},
+		{
+			name: "duplicate code",
+			setup: func(r *Registry) error {
+				_, err := r.Register(ErrUnknown.rosErr.Code, "duplicate", false, "")
+				return err
+			},
+			wantErr: "code already used by unknown",
+		},
+		{
+			name: "nil error",
+			setup: func(r *Registry) error {
+				return r.Add(nil)
+			},
+			wantErr: "nil error",
+		},
+		{
+			name: "sealed",
+			setup: func(r *Registry) error {
+				if err := r.Seal(); err != nil {
+					return err
+				}
+				_, err := r.Register(9201, "too late", false, "")
+				return err
+			},
+			wantErr: "registry is sealed",
+		},
+		{
+			name: "seal reports failed package registrations",
+			setup: func(r *Registry) error {
+				r.mustAdd(newError(9202, "first", false, "", http.StatusInternalServerError))
+				r.mustAdd(newError(9202, "second", false, "", http.StatusInternalServerError))
+				return r.Seal()
+			},
+			wantErr: "invalid error registry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.setup(NewRegistry())
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRegistryHTTPStatus(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.RegisterWithHTTPStatus(9300, "not found", false, "", http.StatusNotFound); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		code int32
+		want int
+	}{
+		{9300, http.StatusNotFound},
+		{ErrTimeout.rosErr.Code, ErrTimeout.HTTPStatus()},
+		{9999, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := r.HTTPStatus(tt.code); got != tt.want {
+			t.Errorf("code %d: expected HTTP status %d, got %d", tt.code, tt.want, got)
+		}
+	}
+}
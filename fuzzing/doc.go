@@ -0,0 +1,36 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package fuzzing provides native Go fuzz targets for the construction endpoints, which
+// decode untrusted input and pass it to the client. The package does not import testing,
+// the targets take the Seeder and T subsets of *testing.F and *testing.T and are run
+// from a test file of the client under test, as in fuzz_test.go for the clienttest chain:
+//
+//	func FuzzConstructionParse(f *testing.F) {
+//		h, err := fuzzing.NewHarness(network, client)
+//		if err != nil {
+//			f.Fatal(err)
+//		}
+//		h.SeedConstructionParse(f)
+//		f.Fuzz(func(t *testing.T, tx string, signed bool) {
+//			h.ConstructionParse(t, tx, signed)
+//		})
+//	}
+//
+// and then with go test -fuzz FuzzConstructionParse.
+package fuzzing
@@ -0,0 +1,127 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package fuzzing_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	"github.com/tendermint/cosmos-rosetta-gateway/fuzzing"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// The fuzz targets run their seed corpus with go test, and fuzz with go test -fuzz <target>
+
+// newHarness returns a harness over the clienttest chain, seeded with a valid transfer
+func newHarness(f *testing.F) *fuzzing.Harness {
+	f.Helper()
+	client := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	client.SetBalance(alice.Address, big.NewInt(1000))
+	client.CommitBlock()
+
+	h, err := fuzzing.NewHarness(client.Network(), crgtypes.AdaptClient(client))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	ops := []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                clienttest.OpTransfer,
+			Account:             alice.Identifier(),
+			Amount:              &types.Amount{Value: "-10", Currency: client.Currency()},
+		},
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 1},
+			Type:                clienttest.OpTransfer,
+			Account:             bob.Identifier(),
+			Amount:              &types.Amount{Value: "10", Currency: client.Currency()},
+		},
+	}
+	ctx := context.Background()
+	payloads, err := client.ConstructionPayload(ctx, &types.ConstructionPayloadsRequest{
+		Operations: ops,
+		Metadata:   map[string]interface{}{clienttest.MetadataSequence: uint64(0)},
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	unsigned, err := hex.DecodeString(payloads.UnsignedTransaction)
+	if err != nil {
+		f.Fatal(err)
+	}
+	sig, err := alice.Sign(payloads.Payloads[0])
+	if err != nil {
+		f.Fatal(err)
+	}
+	signed, err := client.SignedTx(ctx, unsigned, []*types.Signature{sig})
+	if err != nil {
+		f.Fatal(err)
+	}
+	if err := h.AddOperationSeeds(ops); err != nil {
+		f.Fatal(err)
+	}
+	h.AddTransactionSeeds(unsigned, signed)
+	return h
+}
+
+func FuzzConstructionParse(f *testing.F) {
+	h := newHarness(f)
+	h.SeedConstructionParse(f)
+	f.Fuzz(func(t *testing.T, tx string, signed bool) {
+		h.ConstructionParse(t, tx, signed)
+	})
+}
+
+func FuzzConstructionCombine(f *testing.F) {
+	h := newHarness(f)
+	h.SeedConstructionCombine(f)
+	f.Fuzz(func(t *testing.T, tx string, payload, sig, pubKey []byte, curve, sigType string, withPubKey bool) {
+		h.ConstructionCombine(t, tx, payload, sig, pubKey, curve, sigType, withPubKey)
+	})
+}
+
+func FuzzConstructionHash(f *testing.F) {
+	h := newHarness(f)
+	h.SeedConstructionHash(f)
+	f.Fuzz(func(t *testing.T, tx string) {
+		h.ConstructionHash(t, tx)
+	})
+}
+
+func FuzzConstructionSubmit(f *testing.F) {
+	h := newHarness(f)
+	h.SeedConstructionSubmit(f)
+	f.Fuzz(func(t *testing.T, tx string, metadata []byte) {
+		h.ConstructionSubmit(t, tx, metadata)
+	})
+}
+
+func FuzzConstructionOperations(f *testing.F) {
+	h := newHarness(f)
+	h.SeedConstructionOperations(f)
+	f.Fuzz(func(t *testing.T, opsJSON, metadata []byte) {
+		h.ConstructionOperations(t, opsJSON, metadata)
+	})
+}
@@ -0,0 +1,230 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package fuzzing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// submitTimeout bounds the submissions waiting for the inclusion of the transaction
+const submitTimeout = 100 * time.Millisecond
+
+// Seeder is the subset of *testing.F used to add the seed corpus of a target
+type Seeder interface {
+	Add(args ...interface{})
+}
+
+// T is the subset of *testing.T used by the targets to report failures
+type T interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Skip(args ...interface{})
+}
+
+// Harness drives the construction endpoints of a client with fuzzed inputs,
+// the client must not panic and must return either a response or an error.
+// Each target has a Seed method adding its seed corpus and a method checking one input,
+// which the fuzz function given to testing.F.Fuzz calls.
+type Harness struct {
+	network *types.NetworkIdentifier
+	online  crgtypes.API
+	offline crgtypes.API
+
+	txs [][]byte
+	ops [][]byte
+}
+
+// NewHarness builds the online and offline services of client,
+// Client implementations are adapted with crgtypes.AdaptClient
+func NewHarness(network *types.NetworkIdentifier, client crgtypes.ClientV2) (*Harness, error) {
+	online, err := service.NewOnlineNetwork(context.Background(), network, client, service.Options{})
+	if err != nil {
+		return nil, err
+	}
+	offline, err := service.NewOffline(context.Background(), network, client, service.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &Harness{network: network, online: online, offline: offline}, nil
+}
+
+// AddTransactionSeeds adds valid transactions to the seed corpus of the targets
+func (h *Harness) AddTransactionSeeds(txs ...[]byte) {
+	h.txs = append(h.txs, txs...)
+}
+
+// AddOperationSeeds adds valid operation lists to the seed corpus of the targets
+func (h *Harness) AddOperationSeeds(ops ...[]*types.Operation) error {
+	for _, o := range ops {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		h.ops = append(h.ops, b)
+	}
+	return nil
+}
+
+// txSeeds returns the hex encoded transaction seeds plus malformed inputs
+func (h *Harness) txSeeds() []string {
+	seeds := []string{"", "0", "zz", "00", "ff"}
+	for _, tx := range h.txs {
+		seeds = append(seeds, hex.EncodeToString(tx), hex.EncodeToString(tx[:len(tx)/2]))
+	}
+	return seeds
+}
+
+// SeedConstructionParse adds the seed corpus of ConstructionParse
+func (h *Harness) SeedConstructionParse(f Seeder) {
+	for _, tx := range h.txSeeds() {
+		f.Add(tx, true)
+		f.Add(tx, false)
+	}
+}
+
+// ConstructionParse fuzzes /construction/parse with random hex, signed and unsigned
+func (h *Harness) ConstructionParse(t T, tx string, signed bool) {
+	resp, err := h.offline.ConstructionParse(context.Background(), &types.ConstructionParseRequest{
+		NetworkIdentifier: h.network,
+		Signed:            signed,
+		Transaction:       tx,
+	})
+	check(t, resp, err)
+}
+
+// SeedConstructionHash adds the seed corpus of ConstructionHash
+func (h *Harness) SeedConstructionHash(f Seeder) {
+	for _, tx := range h.txSeeds() {
+		f.Add(tx)
+	}
+}
+
+// ConstructionHash fuzzes /construction/hash with random hex
+func (h *Harness) ConstructionHash(t T, tx string) {
+	resp, err := h.offline.ConstructionHash(context.Background(), &types.ConstructionHashRequest{
+		NetworkIdentifier: h.network,
+		SignedTransaction: tx,
+	})
+	check(t, resp, err)
+}
+
+// SeedConstructionCombine adds the seed corpus of ConstructionCombine
+func (h *Harness) SeedConstructionCombine(f Seeder) {
+	for _, tx := range h.txSeeds() {
+		f.Add(tx, []byte{}, []byte{}, []byte{}, string(types.Edwards25519), string(types.Ed25519), true)
+		f.Add(tx, make([]byte, 32), make([]byte, 64), make([]byte, 32), string(types.Secp256k1), string(types.Ecdsa), false)
+	}
+}
+
+// ConstructionCombine fuzzes /construction/combine with random hex and a random signature
+func (h *Harness) ConstructionCombine(t T, tx string, payload, sig, pubKey []byte, curve, sigType string, withPubKey bool) {
+	signature := &types.Signature{
+		SigningPayload: &types.SigningPayload{Bytes: payload, SignatureType: types.SignatureType(sigType)},
+		SignatureType:  types.SignatureType(sigType),
+		Bytes:          sig,
+	}
+	if withPubKey {
+		signature.PublicKey = &types.PublicKey{Bytes: pubKey, CurveType: types.CurveType(curve)}
+	}
+	resp, err := h.offline.ConstructionCombine(context.Background(), &types.ConstructionCombineRequest{
+		NetworkIdentifier:   h.network,
+		UnsignedTransaction: tx,
+		Signatures:          []*types.Signature{signature},
+	})
+	check(t, resp, err)
+}
+
+// SeedConstructionSubmit adds the seed corpus of ConstructionSubmit
+func (h *Harness) SeedConstructionSubmit(f Seeder) {
+	for _, tx := range h.txSeeds() {
+		f.Add(tx, []byte(`{}`))
+		f.Add(tx, []byte(`{"wait_for_inclusion": true, "inclusion_timeout": "10ms"}`))
+	}
+}
+
+// ConstructionSubmit fuzzes /construction/submit with random hex and random request metadata
+func (h *Harness) ConstructionSubmit(t T, tx string, metadata []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), submitTimeout)
+	defer cancel()
+	var meta map[string]interface{}
+	if json.Unmarshal(metadata, &meta) == nil {
+		ctx = service.WithRequestMetadata(ctx, meta)
+	}
+	resp, err := h.online.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: h.network,
+		SignedTransaction: tx,
+	})
+	check(t, resp, err)
+}
+
+// SeedConstructionOperations adds the seed corpus of ConstructionOperations
+func (h *Harness) SeedConstructionOperations(f Seeder) {
+	seeds := append([][]byte{[]byte(`[]`), []byte(`[{}]`), []byte(`[{"amount": {"value": "-"}}]`)}, h.ops...)
+	for _, ops := range seeds {
+		f.Add(ops, []byte(`{"sequence": 0}`))
+		f.Add(ops, []byte(`{}`))
+	}
+}
+
+// ConstructionOperations fuzzes /construction/preprocess and /construction/payloads
+// with random operation lists and metadata
+func (h *Harness) ConstructionOperations(t T, opsJSON, metadata []byte) {
+	var ops []*types.Operation
+	if json.Unmarshal(opsJSON, &ops) != nil {
+		t.Skip("invalid operations")
+		return
+	}
+	var meta map[string]interface{}
+	_ = json.Unmarshal(metadata, &meta)
+
+	preprocess, err := h.offline.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: h.network,
+		Operations:        ops,
+	})
+	check(t, preprocess, err)
+
+	payloads, err := h.offline.ConstructionPayloads(context.Background(), &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: h.network,
+		Operations:        ops,
+		Metadata:          meta,
+	})
+	check(t, payloads, err)
+}
+
+// check fails the test if the endpoint returned neither a response nor a valid error
+func check(t T, resp interface{}, err *types.Error) {
+	t.Helper()
+	if err != nil {
+		if err.Message == "" {
+			t.Fatalf("error %d without message", err.Code)
+		}
+		return
+	}
+	if resp == nil || reflect.ValueOf(resp).IsNil() {
+		t.Fatalf("nil response without error")
+	}
+}
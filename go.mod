@@ -1,10 +1,19 @@
 module github.com/tendermint/cosmos-rosetta-gateway
 
-go 1.14
+go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/coinbase/rosetta-sdk-go v0.6.10
-	golang.org/x/sys v0.0.0-20200922070232-aee5d888a860 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.27.0
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
+)
+
+require (
+	github.com/golang/protobuf v1.4.2 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/mitchellh/mapstructure v1.3.3 // indirect
+	golang.org/x/sys v0.0.0-20200922070232-aee5d888a860 // indirect
+	google.golang.org/protobuf v1.25.0 // indirect
 )
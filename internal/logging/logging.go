@@ -0,0 +1,107 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package logging implements the leveled logging of the gateway on top of the standard library logger.
+// The level is process wide and can be changed at runtime.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the minimum severity of the logged messages
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// DefaultLevel is the level used when none is configured
+const DefaultLevel = LevelInfo
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String implements fmt.Stringer
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLevel parses a level name among debug, info, warn and error, case insensitively.
+// An empty name is the DefaultLevel.
+func ParseLevel(s string) (Level, error) {
+	if s == "" {
+		return DefaultLevel, nil
+	}
+	for level, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", s)
+}
+
+var level = int32(DefaultLevel)
+
+// SetLevel sets the minimum level of the logged messages
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// GetLevel returns the minimum level of the logged messages
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+// Enabled reports whether messages of the given level are logged
+func Enabled(l Level) bool {
+	return l >= GetLevel()
+}
+
+// Debugf logs a debug message
+func Debugf(format string, args ...interface{}) { logf(LevelDebug, format, args...) }
+
+// Infof logs an informational message
+func Infof(format string, args ...interface{}) { logf(LevelInfo, format, args...) }
+
+// Warnf logs a warning
+func Warnf(format string, args ...interface{}) { logf(LevelWarn, format, args...) }
+
+// Errorf logs an error
+func Errorf(format string, args ...interface{}) { logf(LevelError, format, args...) }
+
+func logf(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	// the call depth skips logf and the level function so the standard logger flags report the caller
+	_ = log.Output(3, strings.ToUpper(l.String())+" "+fmt.Sprintf(format, args...))
+}
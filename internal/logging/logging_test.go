@@ -0,0 +1,94 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"", DefaultLevel, false},
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"Warn", LevelWarn, false},
+		{"error", LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLevel(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+		SetLevel(DefaultLevel)
+	}()
+
+	logAll := func() {
+		Debugf("d")
+		Infof("i")
+		Warnf("w")
+		Errorf("e")
+	}
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{LevelDebug, "DEBUG d\nINFO i\nWARN w\nERROR e\n"},
+		{LevelInfo, "INFO i\nWARN w\nERROR e\n"},
+		{LevelWarn, "WARN w\nERROR e\n"},
+		{LevelError, "ERROR e\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			buf.Reset()
+			SetLevel(tt.level)
+			logAll()
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if !strings.Contains(Level(9).String(), "9") {
+		t.Fatal("unknown levels should print their value")
+	}
+}
@@ -0,0 +1,99 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// blockCacheSize is the number of blocks kept by blockCache
+const blockCacheSize = 8
+
+// blockCache keeps the transactions of the last blocks fetched to serve /block/transaction
+// without TxBlockProvider, so that fetching the OtherTransactions of a /block response
+// queries the block once instead of once per transaction. It is safe for concurrent use.
+type blockCache struct {
+	mu      sync.Mutex
+	blocks  map[int64]cachedBlock
+	heights []int64 // cached heights, oldest first
+}
+
+// cachedBlock holds the transactions of a block keyed by lower case hash,
+// as hashes are matched case insensitively
+type cachedBlock struct {
+	block *types.BlockIdentifier
+	txs   map[string]*types.Transaction
+}
+
+func newBlockCache() *blockCache {
+	return &blockCache{blocks: make(map[int64]cachedBlock, blockCacheSize)}
+}
+
+// add caches the transactions of the block, evicting the oldest block when full.
+// It is a no-op on a nil cache.
+func (c *blockCache) add(block *types.BlockIdentifier, txs []*types.Transaction) {
+	if c == nil || block == nil {
+		return
+	}
+	cached := cachedBlock{block: block, txs: make(map[string]*types.Transaction, len(txs))}
+	for _, tx := range txs {
+		if tx != nil && tx.TransactionIdentifier != nil {
+			cached.txs[strings.ToLower(tx.TransactionIdentifier.Hash)] = tx
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.blocks[block.Index]; !ok {
+		if len(c.heights) == blockCacheSize {
+			delete(c.blocks, c.heights[0])
+			c.heights = c.heights[1:]
+		}
+		c.heights = append(c.heights, block.Index)
+	}
+	c.blocks[block.Index] = cached
+}
+
+// get returns the cached block with the height and hash of block, false if not cached
+func (c *blockCache) get(block *types.BlockIdentifier) (cachedBlock, bool) {
+	if c == nil {
+		return cachedBlock{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.blocks[block.Index]
+	if !ok || !strings.EqualFold(cached.block.Hash, block.Hash) {
+		return cachedBlock{}, false
+	}
+	return cached, true
+}
+
+// flush removes the cached blocks, it is a no-op on a nil cache
+func (c *blockCache) flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blocks = make(map[int64]cachedBlock, blockCacheSize)
+	c.heights = nil
+}
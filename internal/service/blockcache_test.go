@@ -0,0 +1,79 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+func TestBlockCache(t *testing.T) {
+	blockAt := func(height int64) *types.BlockIdentifier {
+		return &types.BlockIdentifier{Index: height, Hash: fmt.Sprintf("block-%d", height)}
+	}
+	tx := &types.Transaction{TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx"}}
+
+	c := newBlockCache()
+	for height := int64(1); height <= blockCacheSize+1; height++ {
+		c.add(blockAt(height), []*types.Transaction{nil, tx})
+	}
+	// adding a cached height again must not evict another block
+	c.add(blockAt(blockCacheSize+1), []*types.Transaction{tx})
+
+	tests := []struct {
+		name   string
+		block  *types.BlockIdentifier
+		wantOK bool
+	}{
+		{name: "evicted", block: blockAt(1)},
+		{name: "oldest kept", block: blockAt(2), wantOK: true},
+		{name: "newest", block: blockAt(blockCacheSize + 1), wantOK: true},
+		{name: "other hash", block: &types.BlockIdentifier{Index: 2, Hash: "other"}},
+		{name: "not cached", block: blockAt(blockCacheSize + 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached, ok := c.get(tt.block)
+			if ok != tt.wantOK {
+				t.Fatalf("expected cached %t, got %t", tt.wantOK, ok)
+			}
+			if ok && cached.txs["tx"] != tx {
+				t.Fatal("expected the cached transaction")
+			}
+		})
+	}
+
+	c.flush()
+	if _, ok := c.get(blockAt(2)); ok {
+		t.Fatal("expected the flushed cache to be empty")
+	}
+	c.add(blockAt(2), []*types.Transaction{tx})
+	if _, ok := c.get(blockAt(2)); !ok {
+		t.Fatal("expected the flushed cache to be usable")
+	}
+
+	var nilCache *blockCache
+	nilCache.flush()
+	nilCache.add(blockAt(1), []*types.Transaction{tx})
+	if _, ok := nilCache.get(blockAt(1)); ok {
+		t.Fatal("expected a nil cache to be empty")
+	}
+}
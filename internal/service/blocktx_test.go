@@ -0,0 +1,225 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// scanClient is a clienttest client without TxBlockProvider counting the block transactions queries,
+// the block transactions start with a nil transaction when nilTx is set and have meta as metadata
+type scanClient struct {
+	crgtypes.Client
+	scans int
+	nilTx bool
+	meta  *crgtypes.BlockMetadata
+}
+
+func (c *scanClient) BlockTransactionsByHeight(ctx context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	c.scans++
+	res, err := c.Client.BlockTransactionsByHeight(ctx, height)
+	if c.nilTx {
+		res.Transactions = append([]*types.Transaction{nil}, res.Transactions...)
+	}
+	res.Metadata = c.meta
+	return res, err
+}
+
+func TestBlockTransaction(t *testing.T) {
+	c := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	c.SetBalance(alice.Address, big.NewInt(100))
+	c.CommitBlock()
+	tx, err := c.Transfer(alice, bob.Address, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := c.CommitBlock()
+	next := c.CommitBlock()
+	unknown := &types.TransactionIdentifier{Hash: "0000"}
+
+	tests := []struct {
+		name      string
+		block     *types.BlockIdentifier
+		tx        *types.TransactionIdentifier
+		nilTx     bool
+		wantFound bool
+		wantScans int
+	}{
+		{name: "included", block: block, tx: tx, wantFound: true, wantScans: 1},
+		{name: "nil transaction in the block", block: block, tx: tx, nilTx: true, wantFound: true, wantScans: 1},
+		{name: "wrong height", block: &types.BlockIdentifier{Index: next.Index, Hash: block.Hash}, tx: tx, wantScans: 1},
+		{name: "wrong hash", block: &types.BlockIdentifier{Index: block.Index, Hash: next.Hash}, tx: tx, wantScans: 1},
+		{name: "other block", block: next, tx: tx, wantScans: 1},
+		{name: "unknown transaction", block: block, tx: unknown, wantScans: 0},
+		{
+			name:      "hashes in another case",
+			block:     &types.BlockIdentifier{Index: block.Index, Hash: strings.ToLower(block.Hash)},
+			tx:        &types.TransactionIdentifier{Hash: strings.ToLower(tx.Hash)},
+			wantFound: true,
+			wantScans: 1,
+		},
+	}
+	for _, txBlock := range []bool{true, false} {
+		for _, tt := range tests {
+			name := tt.name + " by scan"
+			if txBlock {
+				name = tt.name + " with TxBlockProvider"
+			}
+			t.Run(name, func(t *testing.T) {
+				scan := &scanClient{Client: c, nilTx: tt.nilTx}
+				client := crgtypes.AdaptClient(scan)
+				if txBlock {
+					client = crgtypes.AdaptClient(c)
+				}
+				network, err := service.NewOnlineNetwork(context.Background(), c.Network(), client, service.Options{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				scan.scans = 0
+
+				resp, rosErr := network.BlockTransaction(context.Background(), &types.BlockTransactionRequest{
+					NetworkIdentifier:     c.Network(),
+					BlockIdentifier:       tt.block,
+					TransactionIdentifier: tt.tx,
+				})
+				switch {
+				case tt.wantFound && rosErr != nil:
+					t.Fatalf("unexpected error: %s", rosErr.Message)
+				case !tt.wantFound && (rosErr == nil || rosErr.Code != crgerrs.ToRosetta(crgerrs.ErrNotFound).Code):
+					t.Fatalf("expected ErrNotFound, got %+v", rosErr)
+				case tt.wantFound:
+					if !strings.EqualFold(resp.Transaction.TransactionIdentifier.Hash, tt.tx.Hash) {
+						t.Fatalf("expected tx %s, got %s", tt.tx.Hash, resp.Transaction.TransactionIdentifier.Hash)
+					}
+					if got := resp.Transaction.Metadata[service.TxMetaBlockIdentifier]; got != tt.block {
+						t.Fatalf("expected the block in the metadata, got %v", got)
+					}
+				}
+				if !txBlock && scan.scans != tt.wantScans {
+					t.Fatalf("expected %d block queries, got %d", tt.wantScans, scan.scans)
+				}
+			})
+		}
+	}
+}
+
+func TestBlockInlineTransactions(t *testing.T) {
+	c := clienttest.NewClient(clienttest.Config{})
+	alice := clienttest.NewAccount("alice")
+	c.SetBalance(alice.Address, big.NewInt(100))
+	c.CommitBlock()
+	var txs []*types.TransactionIdentifier
+	for i := 0; i < 4; i++ {
+		tx, err := c.Transfer(alice, clienttest.NewAccount("bob").Address, big.NewInt(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	block := c.CommitBlock()
+	meta := &crgtypes.BlockMetadata{Proposer: "proposer", AppHash: "apphash", EvidenceCount: 1, GasUsed: 2, GasWanted: 3}
+
+	tests := []struct {
+		name      string
+		limit     int
+		meta      *crgtypes.BlockMetadata
+		wantMeta  map[string]interface{}
+		wantOther int
+	}{
+		{name: "no limit", limit: 0, wantOther: 0},
+		{name: "limit above the transactions", limit: 4, wantOther: 0},
+		{
+			name:      "limit below the transactions",
+			limit:     1,
+			meta:      meta,
+			wantOther: 3,
+			wantMeta: map[string]interface{}{
+				service.BlockMetaProposer:      "proposer",
+				service.BlockMetaAppHash:       "apphash",
+				service.BlockMetaEvidenceCount: int64(1),
+				service.BlockMetaGasUsed:       int64(2),
+				service.BlockMetaGasWanted:     int64(3),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := &scanClient{Client: c, meta: tt.meta}
+			network, err := service.NewOnlineNetwork(context.Background(), c.Network(), crgtypes.AdaptClient(scan), service.Options{InlineTransactionsLimit: tt.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			scan.scans = 0
+
+			resp, rosErr := network.Block(context.Background(), &types.BlockRequest{
+				NetworkIdentifier: c.Network(),
+				BlockIdentifier:   &types.PartialBlockIdentifier{Index: &block.Index},
+			})
+			if rosErr != nil {
+				t.Fatalf("unexpected error: %s", rosErr.Message)
+			}
+			if got, want := len(resp.Block.Transactions), len(txs)-tt.wantOther; got != want {
+				t.Fatalf("expected %d inline transactions, got %d", want, got)
+			}
+			if len(resp.OtherTransactions) != tt.wantOther {
+				t.Fatalf("expected %d other transactions, got %d", tt.wantOther, len(resp.OtherTransactions))
+			}
+			if len(resp.Block.Metadata) != len(tt.wantMeta) {
+				t.Fatalf("expected metadata %v, got %v", tt.wantMeta, resp.Block.Metadata)
+			}
+			for k, v := range tt.wantMeta {
+				if resp.Block.Metadata[k] != v {
+					t.Fatalf("expected metadata %s %v, got %v", k, v, resp.Block.Metadata[k])
+				}
+			}
+
+			// the other transactions are served from the block fetched by /block,
+			// the hashes are requested in lower case as they are matched case insensitively
+			for i, other := range resp.OtherTransactions {
+				if other.Hash != txs[len(txs)-tt.wantOther+i].Hash {
+					t.Fatalf("expected other transaction %s, got %s", txs[len(txs)-tt.wantOther+i].Hash, other.Hash)
+				}
+				txResp, rosErr := network.BlockTransaction(context.Background(), &types.BlockTransactionRequest{
+					NetworkIdentifier:     c.Network(),
+					BlockIdentifier:       &types.BlockIdentifier{Index: block.Index, Hash: strings.ToLower(block.Hash)},
+					TransactionIdentifier: &types.TransactionIdentifier{Hash: strings.ToLower(other.Hash)},
+				})
+				if rosErr != nil {
+					t.Fatalf("unexpected error: %s", rosErr.Message)
+				}
+				if txResp.Transaction.TransactionIdentifier.Hash != other.Hash {
+					t.Fatalf("expected tx %s, got %s", other.Hash, txResp.Transaction.TransactionIdentifier.Hash)
+				}
+			}
+			if scan.scans != 1 {
+				t.Fatalf("expected a single block query, got %d", scan.scans)
+			}
+		})
+	}
+}
@@ -22,21 +22,30 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"strings"
+	stderrors "errors"
+	"fmt"
+	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
 )
 
 func (on OnlineNetwork) ConstructionCombine(ctx context.Context, request *types.ConstructionCombineRequest) (*types.ConstructionCombineResponse, *types.Error) {
 	txBytes, err := hex.DecodeString(request.UnsignedTransaction)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(decodeTxError("unsigned_transaction", err))
 	}
 
 	signedTx, err := on.client.SignedTx(ctx, txBytes, request.Signatures)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
+	}
+
+	if on.opts.load().VerifyRoundTrip {
+		if err := on.verifyCombine(ctx, txBytes, signedTx, request.Signatures); err != nil {
+			return nil, on.toRosetta(err)
+		}
 	}
 
 	return &types.ConstructionCombineResponse{
@@ -44,10 +53,10 @@ func (on OnlineNetwork) ConstructionCombine(ctx context.Context, request *types.
 	}, nil
 }
 
-func (on OnlineNetwork) ConstructionDerive(_ context.Context, request *types.ConstructionDeriveRequest) (*types.ConstructionDeriveResponse, *types.Error) {
-	account, err := on.client.AccountIdentifierFromPublicKey(request.PublicKey)
+func (on OnlineNetwork) ConstructionDerive(ctx context.Context, request *types.ConstructionDeriveRequest) (*types.ConstructionDeriveResponse, *types.Error) {
+	account, err := on.client.AccountIdentifierFromPublicKey(ctx, request.PublicKey)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 	return &types.ConstructionDeriveResponse{
 		AccountIdentifier: account,
@@ -58,40 +67,93 @@ func (on OnlineNetwork) ConstructionDerive(_ context.Context, request *types.Con
 func (on OnlineNetwork) ConstructionHash(ctx context.Context, request *types.ConstructionHashRequest) (*types.TransactionIdentifierResponse, *types.Error) {
 	bz, err := hex.DecodeString(request.SignedTransaction)
 	if err != nil {
-		return nil, errors.ToRosetta(errors.WrapError(errors.ErrInvalidTransaction, "error decoding tx"))
+		return nil, on.toRosetta(decodeTxError("signed_transaction", err))
 	}
 
-	hash := sha256.Sum256(bz)
-	bzHash := hash[:]
-	hashString := hex.EncodeToString(bzHash)
+	hash, err := on.hashTx(bz)
+	if err != nil {
+		return nil, on.toRosetta(err)
+	}
 
 	return &types.TransactionIdentifierResponse{
 		TransactionIdentifier: &types.TransactionIdentifier{
-			Hash: strings.ToUpper(hashString),
+			Hash: hash,
 		},
 	}, nil
 }
 
+// validateTxHasher checks the hash format of the client, if it implements TxHasher
+func validateTxHasher(client interface{}) error {
+	hasher, ok := crgtypes.WithCapability(client, crgtypes.CapabilityTxHash).(crgtypes.TxHasher)
+	if !ok {
+		return nil
+	}
+	if err := hasher.TxHashFormat().Validate(); err != nil {
+		return fmt.Errorf("invalid client tx hasher: %w", err)
+	}
+	return nil
+}
+
+// hashTx returns the formatted hash of the signed transaction, computed by the client
+// if it implements TxHasher, otherwise as the upper hex sha256 of the transaction
+func (on OnlineNetwork) hashTx(bz []byte) (string, error) {
+	hash, err := on.clientHashTx(bz)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrInvalidTransaction, err.Error())
+	}
+	return hash, nil
+}
+
+// clientHashTx is hashTx returning the error of the client as is
+func (on OnlineNetwork) clientHashTx(bz []byte) (string, error) {
+	hasher, ok := on.capability(crgtypes.CapabilityTxHash).(crgtypes.TxHasher)
+	if !ok {
+		hash := sha256.Sum256(bz)
+		return crgtypes.FormatTxHash(crgtypes.TxHashFormatUpperHex, hash[:]), nil
+	}
+
+	hash, err := hasher.HashTx(bz)
+	if err != nil {
+		return "", err
+	}
+	return crgtypes.FormatTxHash(hasher.TxHashFormat(), hash), nil
+}
+
 func (on OnlineNetwork) ConstructionMetadata(ctx context.Context, request *types.ConstructionMetadataRequest) (*types.ConstructionMetadataResponse, *types.Error) {
 	metadata, err := on.client.ConstructionMetadataFromOptions(ctx, request.Options)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
+	}
+
+	simulator, ok := on.capability(crgtypes.CapabilityTxSimulation).(crgtypes.TxSimulator)
+	if !ok {
+		return &types.ConstructionMetadataResponse{
+			Metadata: metadata,
+		}, nil
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	fee, err := on.estimateFee(ctx, simulator, request, metadata)
+	if err != nil {
+		return nil, on.toRosetta(err)
 	}
 
 	return &types.ConstructionMetadataResponse{
-		Metadata: metadata,
+		Metadata:     metadata,
+		SuggestedFee: fee,
 	}, nil
 }
 
 func (on OnlineNetwork) ConstructionParse(ctx context.Context, request *types.ConstructionParseRequest) (*types.ConstructionParseResponse, *types.Error) {
 	txBytes, err := hex.DecodeString(request.Transaction)
 	if err != nil {
-		err := errors.WrapError(errors.ErrInvalidTransaction, err.Error())
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(decodeTxError("transaction", err))
 	}
-	ops, signers, err := on.client.TxOperationsAndSignersAccountIdentifiers(request.Signed, txBytes)
+	ops, signers, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, request.Signed, txBytes)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 	return &types.ConstructionParseResponse{
 		Operations:               ops,
@@ -102,17 +164,31 @@ func (on OnlineNetwork) ConstructionParse(ctx context.Context, request *types.Co
 }
 
 func (on OnlineNetwork) ConstructionPayloads(ctx context.Context, request *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, *types.Error) {
+	if err := on.operationSchemas.validate(request.Operations); err != nil {
+		return nil, on.toRosetta(err)
+	}
+
 	payload, err := on.client.ConstructionPayload(ctx, request)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
+	}
+
+	if on.opts.load().VerifyRoundTrip {
+		if err := on.verifyPayloads(ctx, request, payload); err != nil {
+			return nil, on.toRosetta(err)
+		}
 	}
 	return payload, nil
 }
 
 func (on OnlineNetwork) ConstructionPreprocess(ctx context.Context, request *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, *types.Error) {
+	if err := on.operationSchemas.validate(request.Operations); err != nil {
+		return nil, on.toRosetta(err)
+	}
+
 	options, err := on.client.PreprocessOperationsToOptions(ctx, request)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
 	return options, nil
@@ -121,12 +197,33 @@ func (on OnlineNetwork) ConstructionPreprocess(ctx context.Context, request *typ
 func (on OnlineNetwork) ConstructionSubmit(ctx context.Context, request *types.ConstructionSubmitRequest) (*types.TransactionIdentifierResponse, *types.Error) {
 	txBytes, err := hex.DecodeString(request.SignedTransaction)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(decodeTxError("signed_transaction", err))
 	}
 
-	res, meta, err := on.client.PostTx(txBytes)
+	opts, err := submitOptionsFromMetadata(requestMetadataFromContext(ctx))
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
+	}
+
+	var waiter crgtypes.TxInclusionWaiter
+	if opts.waitForInclusion {
+		var ok bool
+		waiter, ok = on.capability(crgtypes.CapabilityTxInclusion).(crgtypes.TxInclusionWaiter)
+		if !ok {
+			return nil, on.toRosetta(errors.WrapError(errors.ErrNotImplemented, "client does not support waiting for tx inclusion"))
+		}
+	}
+
+	res, meta, err := on.client.PostTx(ctx, txBytes)
+	if err != nil {
+		return nil, on.toRosetta(err)
+	}
+
+	if waiter != nil {
+		meta, err = waitTxInclusion(ctx, waiter, res.Hash, opts.inclusionTimeout, meta)
+		if err != nil {
+			return nil, on.toRosetta(err)
+		}
 	}
 
 	return &types.TransactionIdentifierResponse{
@@ -134,3 +231,67 @@ func (on OnlineNetwork) ConstructionSubmit(ctx context.Context, request *types.C
 		Metadata:              meta,
 	}, nil
 }
+
+// inclusionDeadlineMargin is left between the end of the inclusion wait and the deadline of the request,
+// so that the broadcast transaction is reported before the endpoint times out
+const inclusionDeadlineMargin = time.Second
+
+// inclusionWaitTimeout returns the inclusion timeout capped to the remaining time of ctx minus
+// a margin, which is inclusionDeadlineMargin or a tenth of the remaining time if shorter
+func inclusionWaitTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout
+	}
+	remaining := time.Until(deadline)
+	margin := inclusionDeadlineMargin
+	if remaining/10 < margin {
+		margin = remaining / 10
+	}
+	if remaining-margin < timeout {
+		return remaining - margin
+	}
+	return timeout
+}
+
+// waitTxInclusion waits for the tx to be included in a block and adds the inclusion result to meta,
+// if the timeout or the deadline of ctx expires first the tx is reported as not included, as it was
+// broadcast already and a timeout error would make clients submit it again
+func waitTxInclusion(ctx context.Context, waiter crgtypes.TxInclusionWaiter, hash string, timeout time.Duration, meta map[string]interface{}) (map[string]interface{}, error) {
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+
+	timeout = inclusionWaitTimeout(ctx, timeout)
+	if timeout <= 0 {
+		meta[SubmitMetaIncluded] = false
+		return meta, nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := waiter.WaitTxInclusion(waitCtx, hash)
+	switch {
+	// the tx was broadcast correctly, not seeing it included in time is not an error
+	case stderrors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		meta[SubmitMetaIncluded] = false
+		return meta, nil
+	case err != nil:
+		return nil, err
+	}
+
+	meta[SubmitMetaIncluded] = true
+	meta[SubmitMetaBlockIdentifier] = result.Block
+	meta[SubmitMetaCode] = result.Code
+	meta[SubmitMetaCodespace] = result.Codespace
+	meta[SubmitMetaGasWanted] = result.GasWanted
+	meta[SubmitMetaGasUsed] = result.GasUsed
+	meta[SubmitMetaLog] = result.Log
+	return meta, nil
+}
+
+// decodeTxError returns the error of a transaction that could not be hex decoded from the given request field
+func decodeTxError(field string, cause error) error {
+	return errors.Wrap(errors.WrapError(errors.ErrInvalidTransaction, "error decoding tx"), cause).
+		WithDetail(errors.DetailsField, field)
+}
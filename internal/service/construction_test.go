@@ -0,0 +1,184 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// hasherClient is a clienttest client with a configurable TxHasher
+type hasherClient struct {
+	*clienttest.Client
+	format crgtypes.TxHashFormat
+}
+
+func (c hasherClient) HashTx(txBytes []byte) ([]byte, error) {
+	return txBytes, nil
+}
+
+func (c hasherClient) TxHashFormat() crgtypes.TxHashFormat {
+	return c.format
+}
+
+func TestTxHasherFormatValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  crgtypes.TxHashFormat
+		wantErr bool
+	}{
+		{"upper hex", crgtypes.TxHashFormatUpperHex, false},
+		{"lower hex", crgtypes.TxHashFormatLowerHex, false},
+		{"prefixed lower hex", crgtypes.TxHashFormatPrefixedLowerHex, false},
+		{"unknown", "lower-hex", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := clienttest.NewClient(clienttest.Config{})
+			client := crgtypes.AdaptClient(hasherClient{Client: c, format: tt.format})
+
+			_, onlineErr := service.NewOnlineNetwork(context.Background(), c.Network(), client, service.Options{})
+			_, offlineErr := service.NewOffline(context.Background(), c.Network(), client, service.Options{})
+			for _, err := range []error{onlineErr, offlineErr} {
+				switch {
+				case tt.wantErr && (err == nil || !strings.Contains(err.Error(), "tx hash format")):
+					t.Fatalf("expected tx hash format error, got %v", err)
+				case !tt.wantErr && err != nil:
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// simulatorClient is a clienttest client implementing TxSimulator
+type simulatorClient struct {
+	*clienttest.Client
+	gasUsed uint64
+	err     error
+}
+
+func (c simulatorClient) SimulateTx(context.Context, map[string]interface{}, []*types.PublicKey) (uint64, error) {
+	return c.gasUsed, c.err
+}
+
+// minGasPricesClient is a simulatorClient implementing MinGasPricesProvider
+type minGasPricesClient struct {
+	simulatorClient
+	prices []*types.Amount
+}
+
+func (c minGasPricesClient) MinGasPrices(context.Context) ([]*types.Amount, error) {
+	return c.prices, nil
+}
+
+func TestConstructionMetadataFee(t *testing.T) {
+	c := clienttest.NewClient(clienttest.Config{})
+	alice := clienttest.NewAccount("alice")
+	configured := []*types.Amount{{Value: "0.5", Currency: c.Currency()}}
+	onChain := []*types.Amount{{Value: "0.1", Currency: c.Currency()}}
+
+	tests := []struct {
+		name         string
+		client       crgtypes.Client
+		opts         service.Options
+		wantErr      bool
+		wantEstimate interface{}
+		wantLimit    interface{}
+		wantFee      []*types.Amount
+	}{
+		{name: "no simulation", client: c},
+		{
+			name:         "no gas prices",
+			client:       simulatorClient{Client: c, gasUsed: 1000},
+			wantEstimate: uint64(1000),
+			wantLimit:    uint64(1500),
+		},
+		{
+			name:         "configured gas prices",
+			client:       minGasPricesClient{simulatorClient: simulatorClient{Client: c, gasUsed: 1000}, prices: onChain},
+			opts:         service.Options{GasPrices: configured, GasAdjustment: 1.1},
+			wantEstimate: uint64(1000),
+			wantLimit:    uint64(1100),
+			wantFee:      []*types.Amount{{Value: "550", Currency: c.Currency()}},
+		},
+		{
+			name:         "on-chain minimum gas prices",
+			client:       minGasPricesClient{simulatorClient: simulatorClient{Client: c, gasUsed: 1000}, prices: onChain},
+			wantEstimate: uint64(1000),
+			wantLimit:    uint64(1500),
+			wantFee:      []*types.Amount{{Value: "150", Currency: c.Currency()}},
+		},
+		{
+			name:    "simulation error",
+			client:  simulatorClient{Client: c, err: errors.New("simulation failed")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid on-chain gas prices",
+			client:  minGasPricesClient{simulatorClient: simulatorClient{Client: c, gasUsed: 1000}, prices: []*types.Amount{{Value: "-1", Currency: c.Currency()}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, err := service.NewOnlineNetwork(context.Background(), c.Network(), crgtypes.AdaptClient(tt.client), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, rosErr := network.ConstructionMetadata(context.Background(), &types.ConstructionMetadataRequest{
+				NetworkIdentifier: c.Network(),
+				Options:           map[string]interface{}{clienttest.OptionFrom: alice.Address},
+			})
+			switch {
+			case tt.wantErr && rosErr == nil:
+				t.Fatal("expected error")
+			case !tt.wantErr && rosErr != nil:
+				t.Fatalf("unexpected error: %s", rosErr.Message)
+			case tt.wantErr:
+				return
+			}
+
+			if got := resp.Metadata[service.MetadataGasEstimate]; got != tt.wantEstimate {
+				t.Fatalf("expected gas estimate %v, got %v", tt.wantEstimate, got)
+			}
+			if got := resp.Metadata[service.MetadataGasLimit]; got != tt.wantLimit {
+				t.Fatalf("expected gas limit %v, got %v", tt.wantLimit, got)
+			}
+			if _, ok := resp.Metadata[clienttest.MetadataSequence]; !ok {
+				t.Fatal("expected the client metadata to be kept")
+			}
+			if len(resp.SuggestedFee) != len(tt.wantFee) {
+				t.Fatalf("expected fee %v, got %v", tt.wantFee, resp.SuggestedFee)
+			}
+			for i, amount := range resp.SuggestedFee {
+				if amount.Value != tt.wantFee[i].Value || amount.Currency != tt.wantFee[i].Currency {
+					t.Fatalf("expected fee %v, got %v", tt.wantFee[i], amount)
+				}
+			}
+		})
+	}
+}
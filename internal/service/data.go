@@ -20,9 +20,12 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
 	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
 )
 
@@ -39,25 +42,25 @@ func (on OnlineNetwork) AccountBalance(ctx context.Context, request *types.Accou
 	case request.BlockIdentifier == nil:
 		block, err = on.client.BlockByHeight(ctx, nil)
 		if err != nil {
-			return nil, errors.ToRosetta(err)
+			return nil, on.toRosetta(err)
 		}
 	case request.BlockIdentifier.Hash != nil:
 		block, err = on.client.BlockByHash(ctx, *request.BlockIdentifier.Hash)
 		if err != nil {
-			return nil, errors.ToRosetta(err)
+			return nil, on.toRosetta(err)
 		}
 		height = block.Block.Index
 	case request.BlockIdentifier.Index != nil:
 		height = *request.BlockIdentifier.Index
 		block, err = on.client.BlockByHeight(ctx, &height)
 		if err != nil {
-			return nil, errors.ToRosetta(err)
+			return nil, on.toRosetta(err)
 		}
 	}
 
 	accountCoins, err := on.client.Balances(ctx, request.AccountIdentifier.Address, &height)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
 	return &types.AccountBalanceResponse{
@@ -79,48 +82,167 @@ func (on OnlineNetwork) Block(ctx context.Context, request *types.BlockRequest)
 	case request.BlockIdentifier.Hash != nil:
 		blockResponse, err = on.client.BlockTransactionsByHash(ctx, *request.BlockIdentifier.Hash)
 		if err != nil {
-			return nil, errors.ToRosetta(err)
+			return nil, on.toRosetta(err)
 		}
 	case request.BlockIdentifier.Index != nil:
 		blockResponse, err = on.client.BlockTransactionsByHeight(ctx, request.BlockIdentifier.Index)
 		if err != nil {
-			return nil, errors.ToRosetta(err)
+			return nil, on.toRosetta(err)
 		}
 	default:
 		err := errors.WrapError(errors.ErrBadArgument, "at least one of hash or index needs to be specified")
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
+	txs, otherTxs := splitBlockTransactions(blockResponse.Transactions, on.opts.load().InlineTransactionsLimit)
+	if len(otherTxs) != 0 {
+		// the other transactions are fetched next through /block/transaction
+		on.blocks.add(blockResponse.Block, blockResponse.Transactions)
+	}
 	return &types.BlockResponse{
 		Block: &types.Block{
 			BlockIdentifier:       blockResponse.Block,
 			ParentBlockIdentifier: blockResponse.ParentBlock,
 			Timestamp:             blockResponse.MillisecondTimestamp,
-			Transactions:          blockResponse.Transactions,
-			Metadata:              nil,
+			Transactions:          txs,
+			Metadata:              blockMetadata(blockResponse.Metadata),
 		},
-		OtherTransactions: nil,
+		OtherTransactions: otherTxs,
 	}, nil
 }
 
-// BlockTransaction gets the given transaction in the specified block, we do not need to check the block itself too
-// due to the fact that tendermint achieves instant finality
+// splitBlockTransactions returns the first limit transactions and the identifiers of the
+// remaining ones, which clients fetch through /block/transaction. Zero limit means no limit.
+func splitBlockTransactions(txs []*types.Transaction, limit int) ([]*types.Transaction, []*types.TransactionIdentifier) {
+	if limit <= 0 || len(txs) <= limit {
+		return txs, nil
+	}
+	other := make([]*types.TransactionIdentifier, len(txs)-limit)
+	for i, tx := range txs[limit:] {
+		other[i] = tx.TransactionIdentifier
+	}
+	return txs[:limit], other
+}
+
+// BlockTransaction gets the given transaction in the specified block, ErrNotFound is returned if the
+// transaction is not included in the block. The block is added to the transaction metadata.
+// Hashes are matched case insensitively, as by /mempool/transaction.
 func (on OnlineNetwork) BlockTransaction(ctx context.Context, request *types.BlockTransactionRequest) (*types.BlockTransactionResponse, *types.Error) {
-	tx, err := on.client.GetTx(ctx, request.TransactionIdentifier.Hash)
+	var (
+		tx  *types.Transaction
+		err error
+	)
+	if provider, ok := on.capability(crgtypes.CapabilityTxBlock).(crgtypes.TxBlockProvider); ok {
+		tx, err = on.blockTransaction(ctx, provider, request)
+	} else {
+		tx, err = on.findBlockTransaction(ctx, request)
+	}
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
 	return &types.BlockTransactionResponse{
-		Transaction: tx,
+		Transaction: withBlockMetadata(tx, request.BlockIdentifier),
 	}, nil
 }
 
+// blockTransaction gets the transaction after checking its block matches the requested one
+func (on OnlineNetwork) blockTransaction(ctx context.Context, provider crgtypes.TxBlockProvider, request *types.BlockTransactionRequest) (*types.Transaction, error) {
+	hash := request.TransactionIdentifier.Hash
+	block, err := provider.TxBlock(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil || block.Index != request.BlockIdentifier.Index || !strings.EqualFold(block.Hash, request.BlockIdentifier.Hash) {
+		return nil, txNotInBlockError(hash, request.BlockIdentifier)
+	}
+	tx, err := on.client.GetTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, txNotInBlockError(hash, request.BlockIdentifier)
+	}
+	return tx, nil
+}
+
+// findBlockTransaction gets the transaction and verifies its inclusion among the transactions of the
+// requested block, unknown transactions are reported without fetching the block. The scanned blocks
+// and the ones split by /block are cached, so their transactions are served without querying the client.
+func (on OnlineNetwork) findBlockTransaction(ctx context.Context, request *types.BlockTransactionRequest) (*types.Transaction, error) {
+	hash := request.TransactionIdentifier.Hash
+	if cached, ok := on.blocks.get(request.BlockIdentifier); ok {
+		if tx, ok := cached.txs[strings.ToLower(hash)]; ok {
+			return tx, nil
+		}
+		return nil, txNotInBlockError(hash, request.BlockIdentifier)
+	}
+
+	tx, err := on.client.GetTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, txNotInBlockError(hash, request.BlockIdentifier)
+	}
+
+	index := request.BlockIdentifier.Index
+	block, err := on.client.BlockTransactionsByHeight(ctx, &index)
+	if err != nil {
+		return nil, err
+	}
+	if block.Block == nil || !strings.EqualFold(block.Block.Hash, request.BlockIdentifier.Hash) {
+		return nil, txNotInBlockError(hash, request.BlockIdentifier)
+	}
+	on.blocks.add(block.Block, block.Transactions)
+	for _, blockTx := range block.Transactions {
+		if blockTx != nil && blockTx.TransactionIdentifier != nil && strings.EqualFold(blockTx.TransactionIdentifier.Hash, hash) {
+			return tx, nil
+		}
+	}
+	return nil, txNotInBlockError(hash, request.BlockIdentifier)
+}
+
+func txNotInBlockError(hash string, block *types.BlockIdentifier) error {
+	return errors.WrapError(errors.ErrNotFound, fmt.Sprintf("tx %s not found in block %d %s", hash, block.Index, block.Hash))
+}
+
+// withBlockMetadata returns a copy of tx with the block added to its metadata,
+// the transaction returned by the client is not modified as it can be cached
+func withBlockMetadata(tx *types.Transaction, block *types.BlockIdentifier) *types.Transaction {
+	meta := make(map[string]interface{}, len(tx.Metadata)+1)
+	for k, v := range tx.Metadata {
+		meta[k] = v
+	}
+	meta[TxMetaBlockIdentifier] = block
+	withMeta := *tx
+	withMeta.Metadata = meta
+	return &withMeta
+}
+
 // Mempool fetches the transactions contained in the mempool
 func (on OnlineNetwork) Mempool(ctx context.Context, _ *types.NetworkRequest) (*types.MempoolResponse, *types.Error) {
-	txs, err := on.client.Mempool(ctx)
+	if provider, ok := on.capability(crgtypes.CapabilityMempoolTxs).(crgtypes.MempoolTxsProvider); ok {
+		txs, err := on.mempoolTxs(ctx, provider)
+		if err != nil {
+			return nil, on.toRosetta(err)
+		}
+		ids := make([]*types.TransactionIdentifier, len(txs))
+		for i, tx := range txs {
+			ids[i] = &types.TransactionIdentifier{Hash: tx.hash}
+		}
+		return &types.MempoolResponse{
+			TransactionIdentifiers: ids,
+		}, nil
+	}
+
+	mempool, ok := on.capability(crgtypes.CapabilityMempool).(crgtypes.MempoolProvider)
+	if !ok {
+		return nil, on.toRosetta(errors.WrapError(errors.ErrNotImplemented, "client does not support mempool queries"))
+	}
+	txs, err := mempool.Mempool(ctx)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
 	return &types.MempoolResponse{
@@ -128,12 +250,27 @@ func (on OnlineNetwork) Mempool(ctx context.Context, _ *types.NetworkRequest) (*
 	}, nil
 }
 
-// MempoolTransaction fetches a single transaction in the mempool
-// NOTE: it is not implemented yet
+// MempoolTransaction fetches a single transaction in the mempool, the status of its
+// operations is left empty as the transaction is not yet included in a block
 func (on OnlineNetwork) MempoolTransaction(ctx context.Context, request *types.MempoolTransactionRequest) (*types.MempoolTransactionResponse, *types.Error) {
-	tx, err := on.client.GetUnconfirmedTx(ctx, request.TransactionIdentifier.Hash)
+	if provider, ok := on.capability(crgtypes.CapabilityMempoolTxs).(crgtypes.MempoolTxsProvider); ok {
+		resp, err := on.mempoolTransaction(ctx, provider, request.TransactionIdentifier.Hash)
+		if err != nil {
+			return nil, on.toRosetta(err)
+		}
+		return resp, nil
+	}
+
+	provider, ok := on.capability(crgtypes.CapabilityUnconfirmedTx).(crgtypes.UnconfirmedTxProvider)
+	if !ok {
+		return nil, on.toRosetta(errors.WrapError(errors.ErrNotImplemented, "client does not support mempool transaction queries"))
+	}
+	tx, err := provider.GetUnconfirmedTx(ctx, request.TransactionIdentifier.Hash)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
+	}
+	if tx != nil {
+		clearOperationsStatus(tx.Operations)
 	}
 
 	return &types.MempoolTransactionResponse{
@@ -141,6 +278,74 @@ func (on OnlineNetwork) MempoolTransaction(ctx context.Context, request *types.M
 	}, nil
 }
 
+// hashedMempoolTx is a mempool transaction with its hash
+type hashedMempoolTx struct {
+	hash string
+	tx   crgtypes.MempoolTx
+}
+
+// mempoolTxs returns the mempool transactions of the provider with their hashes,
+// the transactions which cannot be hashed are skipped and logged
+func (on OnlineNetwork) mempoolTxs(ctx context.Context, provider crgtypes.MempoolTxsProvider) ([]hashedMempoolTx, error) {
+	txs, err := provider.MempoolTxs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hashed := make([]hashedMempoolTx, 0, len(txs))
+	for i, tx := range txs {
+		hash, err := on.clientHashTx(tx.Bytes)
+		if err != nil {
+			logging.Warnf("skipping mempool transaction %d of %d bytes: %s", i, len(tx.Bytes), err)
+			continue
+		}
+		hashed = append(hashed, hashedMempoolTx{hash: hash, tx: tx})
+	}
+	return hashed, nil
+}
+
+// mempoolTransaction finds the mempool transaction with the given hash and decodes its operations.
+// Hashes are compared case insensitively. Each call fetches and hashes the whole mempool,
+// as MempoolTxsProvider cannot look transactions up by hash.
+func (on OnlineNetwork) mempoolTransaction(ctx context.Context, provider crgtypes.MempoolTxsProvider, hash string) (*types.MempoolTransactionResponse, error) {
+	txs, err := on.mempoolTxs(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if !strings.EqualFold(tx.hash, hash) {
+			continue
+		}
+		ops, _, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, true, tx.tx.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		clearOperationsStatus(ops)
+
+		meta := map[string]interface{}{
+			MempoolMetaSize: len(tx.tx.Bytes),
+		}
+		if tx.tx.Fee != nil {
+			meta[MempoolMetaFee] = tx.tx.Fee
+		}
+		return &types.MempoolTransactionResponse{
+			Transaction: &types.Transaction{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: tx.hash},
+				Operations:            ops,
+			},
+			Metadata: meta,
+		}, nil
+	}
+	return nil, errors.WrapError(errors.ErrNotFound, fmt.Sprintf("tx %s not found in mempool", hash))
+}
+
+// clearOperationsStatus removes the status of the operations, which must be empty
+// for the transactions not yet included in a block
+func clearOperationsStatus(ops []*types.Operation) {
+	for _, op := range ops {
+		op.Status = nil
+	}
+}
+
 func (on OnlineNetwork) NetworkList(_ context.Context, _ *types.MetadataRequest) (*types.NetworkListResponse, *types.Error) {
 	return &types.NetworkListResponse{NetworkIdentifiers: []*types.NetworkIdentifier{on.network}}, nil
 }
@@ -152,17 +357,20 @@ func (on OnlineNetwork) NetworkOptions(_ context.Context, _ *types.NetworkReques
 func (on OnlineNetwork) NetworkStatus(ctx context.Context, _ *types.NetworkRequest) (*types.NetworkStatusResponse, *types.Error) {
 	block, err := on.client.BlockByHeight(ctx, nil)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
-	peers, err := on.client.Peers(ctx)
-	if err != nil {
-		return nil, errors.ToRosetta(err)
+	peers := []*types.Peer{}
+	if provider, ok := on.capability(crgtypes.CapabilityPeers).(crgtypes.PeerProvider); ok {
+		peers, err = provider.Peers(ctx)
+		if err != nil {
+			return nil, on.toRosetta(err)
+		}
 	}
 
 	syncStatus, err := on.client.Status(ctx)
 	if err != nil {
-		return nil, errors.ToRosetta(err)
+		return nil, on.toRosetta(err)
 	}
 
 	return &types.NetworkStatusResponse{
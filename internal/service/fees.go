@@ -0,0 +1,118 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// Construction metadata keys populated when the client supports simulation
+const (
+	// MetadataGasEstimate is the gas consumed by the simulated transaction
+	MetadataGasEstimate = "gas_estimate"
+	// MetadataGasLimit is the gas estimate multiplied by the gas adjustment,
+	// clients are expected to use it as gas limit when building the payloads
+	MetadataGasLimit = "gas_limit"
+)
+
+// DefaultGasAdjustment is the gas adjustment used when none is configured
+const DefaultGasAdjustment = 1.5
+
+// estimateFee simulates the transaction described by the metadata request and
+// adds the gas estimates to meta, it also returns the suggested fee if gas prices are known.
+func (on OnlineNetwork) estimateFee(ctx context.Context, simulator crgtypes.TxSimulator, request *types.ConstructionMetadataRequest, meta map[string]interface{}) ([]*types.Amount, error) {
+	gasUsed, err := simulator.SimulateTx(ctx, request.Options, request.PublicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := on.opts.load()
+	gasLimit := adjustGas(gasUsed, opts.GasAdjustment)
+	meta[MetadataGasEstimate] = gasUsed
+	meta[MetadataGasLimit] = gasLimit
+
+	gasPrices := opts.GasPrices
+	if len(gasPrices) == 0 {
+		provider, ok := on.capability(crgtypes.CapabilityMinGasPrices).(crgtypes.MinGasPricesProvider)
+		if !ok {
+			return nil, nil
+		}
+		gasPrices, err = provider.MinGasPrices(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return feeFromGasPrices(gasPrices, gasLimit)
+}
+
+// adjustGas multiplies the gas by the adjustment factor, rounding up
+func adjustGas(gas uint64, adjustment float64) uint64 {
+	if adjustment <= 0 {
+		adjustment = DefaultGasAdjustment
+	}
+	adjusted := math.Ceil(float64(gas) * adjustment)
+	if adjusted >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return uint64(adjusted)
+}
+
+// feeFromGasPrices computes the fee required for gasLimit in every currency of gasPrices,
+// amounts are rounded up as the node would reject a fee lower than the minimum
+func feeFromGasPrices(gasPrices []*types.Amount, gasLimit uint64) ([]*types.Amount, error) {
+	fee := make([]*types.Amount, 0, len(gasPrices))
+	limit := new(big.Rat).SetInt(new(big.Int).SetUint64(gasLimit))
+	for _, price := range gasPrices {
+		p, err := parseGasPrice(price)
+		if err != nil {
+			return nil, err
+		}
+		total := new(big.Rat).Mul(p, limit)
+		// ceil(num/denom)
+		amount, rem := new(big.Int).QuoRem(total.Num(), total.Denom(), new(big.Int))
+		if rem.Sign() != 0 {
+			amount.Add(amount, big.NewInt(1))
+		}
+		fee = append(fee, &types.Amount{
+			Value:    amount.String(),
+			Currency: price.Currency,
+		})
+	}
+	return fee, nil
+}
+
+// parseGasPrice parses the decimal value of a gas price
+func parseGasPrice(price *types.Amount) (*big.Rat, error) {
+	if price == nil || price.Currency == nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, "gas price without currency")
+	}
+	p, ok := new(big.Rat).SetString(price.Value)
+	if !ok || p.Sign() < 0 {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("invalid gas price %q for %s", price.Value, price.Currency.Symbol))
+	}
+	return p, nil
+}
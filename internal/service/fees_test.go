@@ -0,0 +1,99 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+func TestAdjustGas(t *testing.T) {
+	tests := []struct {
+		name       string
+		gas        uint64
+		adjustment float64
+		want       uint64
+	}{
+		{name: "rounded up", gas: 3, adjustment: 1.1, want: 4},
+		{name: "exact", gas: 100, adjustment: 2, want: 200},
+		{name: "zero adjustment uses the default", gas: 100, adjustment: 0, want: 150},
+		{name: "negative adjustment uses the default", gas: 100, adjustment: -1, want: 150},
+		{name: "clamped to MaxUint64", gas: math.MaxUint64, adjustment: 2, want: math.MaxUint64},
+		{name: "zero gas", gas: 0, adjustment: 1.5, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adjustGas(tt.gas, tt.adjustment); got != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFeeFromGasPrices(t *testing.T) {
+	atom := &types.Currency{Symbol: "uatom", Decimals: 6}
+	stake := &types.Currency{Symbol: "stake", Decimals: 6}
+
+	tests := []struct {
+		name     string
+		prices   []*types.Amount
+		gasLimit uint64
+		want     []string
+		wantErr  bool
+	}{
+		{name: "no prices", gasLimit: 100, want: []string{}},
+		{name: "integer price", prices: []*types.Amount{{Value: "2", Currency: atom}}, gasLimit: 100, want: []string{"200"}},
+		{name: "decimal price rounded up", prices: []*types.Amount{{Value: "0.025", Currency: atom}}, gasLimit: 101, want: []string{"3"}},
+		{name: "exact decimal price", prices: []*types.Amount{{Value: "0.5", Currency: atom}}, gasLimit: 100, want: []string{"50"}},
+		{name: "zero price", prices: []*types.Amount{{Value: "0", Currency: atom}}, gasLimit: 100, want: []string{"0"}},
+		{
+			name:     "every currency",
+			prices:   []*types.Amount{{Value: "0.1", Currency: atom}, {Value: "1", Currency: stake}},
+			gasLimit: 15,
+			want:     []string{"2", "15"},
+		},
+		{name: "negative price", prices: []*types.Amount{{Value: "-1", Currency: atom}}, gasLimit: 100, wantErr: true},
+		{name: "invalid price", prices: []*types.Amount{{Value: "cheap", Currency: atom}}, gasLimit: 100, wantErr: true},
+		{name: "price without currency", prices: []*types.Amount{{Value: "1"}}, gasLimit: 100, wantErr: true},
+		{name: "nil price", prices: []*types.Amount{nil}, gasLimit: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := feeFromGasPrices(tt.prices, tt.gasLimit)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr:
+				return
+			}
+			if len(fee) != len(tt.want) {
+				t.Fatalf("expected %d amounts, got %d", len(tt.want), len(fee))
+			}
+			for i, amount := range fee {
+				if amount.Value != tt.want[i] || amount.Currency != tt.prices[i].Currency {
+					t.Fatalf("expected %s %s, got %s %s", tt.want[i], tt.prices[i].Currency.Symbol, amount.Value, amount.Currency.Symbol)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// unhashableTx cannot be hashed by mempoolClient
+var unhashableTx = []byte("unhashable")
+
+// mempoolClient is a clienttest client hashing transactions as lower hex, whose mempool
+// contains an unhashable transaction and reports the fee of the other transactions
+type mempoolClient struct {
+	*clienttest.Client
+	fee []*types.Amount
+}
+
+func (c mempoolClient) MempoolTxs(ctx context.Context) ([]crgtypes.MempoolTx, error) {
+	txs, err := c.Client.MempoolTxs(ctx)
+	for i := range txs {
+		txs[i].Fee = c.fee
+	}
+	return append([]crgtypes.MempoolTx{{Bytes: unhashableTx}}, txs...), err
+}
+
+func (c mempoolClient) HashTx(txBytes []byte) ([]byte, error) {
+	if string(txBytes) == string(unhashableTx) {
+		return nil, errors.New("cannot decode transaction")
+	}
+	hash := sha256.Sum256(txBytes)
+	return hash[:], nil
+}
+
+func (c mempoolClient) TxHashFormat() crgtypes.TxHashFormat {
+	return crgtypes.TxHashFormatLowerHex
+}
+
+func TestMempoolTxs(t *testing.T) {
+	c := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	c.SetBalance(alice.Address, big.NewInt(100))
+	c.CommitBlock()
+	if _, err := c.Transfer(alice, bob.Address, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := c.MempoolTxs(context.Background())
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected one pending transaction, got %d: %v", len(pending), err)
+	}
+	sum := sha256.Sum256(pending[0].Bytes)
+	hash := hex.EncodeToString(sum[:])
+
+	fee := []*types.Amount{{Value: "5", Currency: c.Currency()}}
+	network, err := service.NewOnlineNetwork(context.Background(), c.Network(), crgtypes.AdaptClient(mempoolClient{Client: c, fee: fee}), service.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the unhashable transaction is skipped
+	mempool, rosErr := network.Mempool(context.Background(), &types.NetworkRequest{NetworkIdentifier: c.Network()})
+	if rosErr != nil {
+		t.Fatalf("unexpected error: %s", rosErr.Message)
+	}
+	if len(mempool.TransactionIdentifiers) != 1 || mempool.TransactionIdentifiers[0].Hash != hash {
+		t.Fatalf("expected the mempool to list %s only, got %+v", hash, mempool.TransactionIdentifiers)
+	}
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr *crgerrs.Error
+	}{
+		{name: "hash in the client format", hash: hash},
+		{name: "hash in another case", hash: strings.ToUpper(hash)},
+		{name: "not in the mempool", hash: strings.Repeat("0", len(hash)), wantErr: crgerrs.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, rosErr := network.MempoolTransaction(context.Background(), &types.MempoolTransactionRequest{
+				NetworkIdentifier:     c.Network(),
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: tt.hash},
+			})
+			if tt.wantErr != nil {
+				if rosErr == nil || rosErr.Code != crgerrs.ToRosetta(tt.wantErr).Code {
+					t.Fatalf("expected error %s, got %+v", tt.wantErr.Error(), rosErr)
+				}
+				return
+			}
+			if rosErr != nil {
+				t.Fatalf("unexpected error: %s", rosErr.Message)
+			}
+			if resp.Transaction.TransactionIdentifier.Hash != hash {
+				t.Errorf("expected the hash %s, got %s", hash, resp.Transaction.TransactionIdentifier.Hash)
+			}
+			if len(resp.Transaction.Operations) != 2 {
+				t.Fatalf("expected the transfer operations, got %+v", resp.Transaction.Operations)
+			}
+			for _, op := range resp.Transaction.Operations {
+				if op.Status != nil {
+					t.Errorf("expected operations without status, got %s", *op.Status)
+				}
+			}
+			if size := resp.Metadata[service.MempoolMetaSize]; size != len(pending[0].Bytes) {
+				t.Errorf("expected size %d, got %v", len(pending[0].Bytes), size)
+			}
+			if got, ok := resp.Metadata[service.MempoolMetaFee].([]*types.Amount); !ok || len(got) != 1 || got[0].Value != "5" {
+				t.Errorf("expected the fee in the metadata, got %v", resp.Metadata[service.MempoolMetaFee])
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// Submit request metadata keys
+const (
+	// SubmitWaitForInclusion is a boolean which makes ConstructionSubmit wait for the tx to be included in a block
+	SubmitWaitForInclusion = "wait_for_inclusion"
+	// SubmitInclusionTimeout is the maximum time to wait for inclusion, expressed as a duration string such as "30s".
+	// The wait also ends shortly before the /construction/submit endpoint timeout, the broadcast transaction
+	// is then reported as not included.
+	SubmitInclusionTimeout = "inclusion_timeout"
+)
+
+// Submit response metadata keys, populated when waiting for inclusion
+const (
+	SubmitMetaIncluded        = "included"
+	SubmitMetaBlockIdentifier = "block_identifier"
+	SubmitMetaCode            = "code"
+	SubmitMetaCodespace       = "codespace"
+	SubmitMetaGasWanted       = "gas_wanted"
+	SubmitMetaGasUsed         = "gas_used"
+	SubmitMetaLog             = "log"
+)
+
+// Block metadata keys, populated when the client provides the block metadata
+const (
+	BlockMetaProposer      = "proposer"
+	BlockMetaAppHash       = "app_hash"
+	BlockMetaEvidenceCount = "evidence_count"
+	BlockMetaGasUsed       = "gas_used"
+	BlockMetaGasWanted     = "gas_wanted"
+)
+
+// blockMetadata returns the rosetta block metadata, nil if the client provides none
+func blockMetadata(meta *crgtypes.BlockMetadata) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		BlockMetaProposer:      meta.Proposer,
+		BlockMetaAppHash:       meta.AppHash,
+		BlockMetaEvidenceCount: meta.EvidenceCount,
+		BlockMetaGasUsed:       meta.GasUsed,
+		BlockMetaGasWanted:     meta.GasWanted,
+	}
+}
+
+// TxMetaBlockIdentifier is the /block/transaction transaction metadata key of the block the transaction was included in
+const TxMetaBlockIdentifier = "block_identifier"
+
+// Mempool transaction response metadata keys, populated when the client implements MempoolTxsProvider
+const (
+	// MempoolMetaSize is the size in bytes of the transaction
+	MempoolMetaSize = "size"
+	// MempoolMetaFee is the fee paid by the transaction, omitted if unknown
+	MempoolMetaFee = "fee"
+)
+
+const (
+	// DefaultInclusionTimeout is the inclusion timeout used when none is provided
+	DefaultInclusionTimeout = 30 * time.Second
+	// MaxInclusionTimeout caps the inclusion timeout a request can ask for,
+	// the endpoint timeout of /construction/submit caps it as well when it is shorter
+	MaxInclusionTimeout = 2 * time.Minute
+)
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a copy of ctx carrying the metadata sent alongside
+// the rosetta request, it is used for requests whose rosetta type does not have
+// a metadata field, such as /construction/submit
+func WithRequestMetadata(ctx context.Context, meta map[string]interface{}) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, meta)
+}
+
+// requestMetadataFromContext returns the request metadata stored in ctx, if any
+func requestMetadataFromContext(ctx context.Context) map[string]interface{} {
+	meta, _ := ctx.Value(requestMetadataKey{}).(map[string]interface{})
+	return meta
+}
+
+// submitOptions defines the options of /construction/submit
+type submitOptions struct {
+	waitForInclusion bool
+	inclusionTimeout time.Duration
+}
+
+// submitOptionsFromMetadata parses the submit options from the request metadata
+func submitOptionsFromMetadata(meta map[string]interface{}) (submitOptions, error) {
+	opts := submitOptions{inclusionTimeout: DefaultInclusionTimeout}
+
+	if v, ok := meta[SubmitWaitForInclusion]; ok {
+		wait, ok := v.(bool)
+		if !ok {
+			return submitOptions{}, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("%s must be a boolean", SubmitWaitForInclusion))
+		}
+		opts.waitForInclusion = wait
+	}
+
+	if v, ok := meta[SubmitInclusionTimeout]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return submitOptions{}, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("%s must be a duration string", SubmitInclusionTimeout))
+		}
+		timeout, err := time.ParseDuration(str)
+		if err != nil || timeout <= 0 {
+			return submitOptions{}, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("invalid %s: %s", SubmitInclusionTimeout, str))
+		}
+		if timeout > MaxInclusionTimeout {
+			timeout = MaxInclusionTimeout
+		}
+		opts.inclusionTimeout = timeout
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,93 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubmitOptionsFromMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    map[string]interface{}
+		want    submitOptions
+		wantErr bool
+	}{
+		{name: "defaults", meta: nil, want: submitOptions{inclusionTimeout: DefaultInclusionTimeout}},
+		{
+			name: "wait with timeout",
+			meta: map[string]interface{}{SubmitWaitForInclusion: true, SubmitInclusionTimeout: "5s"},
+			want: submitOptions{waitForInclusion: true, inclusionTimeout: 5 * time.Second},
+		},
+		{
+			name: "timeout capped",
+			meta: map[string]interface{}{SubmitInclusionTimeout: "1h"},
+			want: submitOptions{inclusionTimeout: MaxInclusionTimeout},
+		},
+		{name: "wait not a boolean", meta: map[string]interface{}{SubmitWaitForInclusion: "true"}, wantErr: true},
+		{name: "timeout not a string", meta: map[string]interface{}{SubmitInclusionTimeout: 5}, wantErr: true},
+		{name: "invalid timeout", meta: map[string]interface{}{SubmitInclusionTimeout: "soon"}, wantErr: true},
+		{name: "negative timeout", meta: map[string]interface{}{SubmitInclusionTimeout: "-1s"}, wantErr: true},
+		{name: "zero timeout", meta: map[string]interface{}{SubmitInclusionTimeout: "0s"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := submitOptionsFromMetadata(tt.meta)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInclusionWaitTimeout(t *testing.T) {
+	deadlineIn := func(d time.Duration) context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		timeout time.Duration
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "no deadline", ctx: context.Background(), timeout: time.Minute, wantMin: time.Minute, wantMax: time.Minute},
+		{name: "deadline after the timeout", ctx: deadlineIn(time.Hour), timeout: time.Minute, wantMin: time.Minute, wantMax: time.Minute},
+		{name: "deadline before the timeout", ctx: deadlineIn(time.Minute), timeout: time.Hour, wantMin: time.Minute - 2*inclusionDeadlineMargin, wantMax: time.Minute - inclusionDeadlineMargin},
+		{name: "short deadline", ctx: deadlineIn(time.Second), timeout: time.Minute, wantMin: 800 * time.Millisecond, wantMax: 900 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inclusionWaitTimeout(tt.ctx, tt.timeout)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Fatalf("expected a timeout between %s and %s, got %s", tt.wantMin, tt.wantMax, got)
+			}
+		})
+	}
+}
@@ -29,14 +29,31 @@ import (
 // NewOffline instantiates the instance of an offline network
 // whilst the offline network does not support the DataAPI,
 // it supports a subset of the construction API.
-func NewOffline(network *types.NetworkIdentifier, client crgtypes.Client) (crgtypes.API, error) {
-	return OfflineNetwork{
+func NewOffline(ctx context.Context, network *types.NetworkIdentifier, client crgtypes.ClientV2, opts Options) (crgtypes.API, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.errorRegistry().Seal(); err != nil {
+		return nil, err
+	}
+	if err := validateTxHasher(crgtypes.UnwrapClient(client)); err != nil {
+		return nil, err
+	}
+	schemas, err := operationSchemasFromClient(crgtypes.UnwrapClient(client))
+	if err != nil {
+		return nil, err
+	}
+	off := OfflineNetwork{
 		OnlineNetwork{
-			client:         client,
-			network:        network,
-			networkOptions: networkOptionsFromClient(client),
+			client:           client,
+			network:          network,
+			networkOptions:   networkOptionsFromClient(ctx, client, opts.errorRegistry()),
+			operationSchemas: schemas,
+			opts:             newOptionsHolder(opts),
+			timeouts:         newTimeoutCounters(),
 		},
-	}, nil
+	}
+	return newTimeoutNetwork(off.OnlineNetwork, off), nil
 }
 
 // OfflineNetwork implements an offline data API
@@ -20,6 +20,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
@@ -32,33 +34,163 @@ import (
 const genesisBlockFetchTimeout = 15 * time.Second
 
 // NewOnlineNetwork builds a single network adapter.
-// It will get the Genesis block on the beginning to avoid calling it everytime.
-func NewOnlineNetwork(network *types.NetworkIdentifier, client crgtypes.Client) (crgtypes.API, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), genesisBlockFetchTimeout)
+// It will get the Genesis block on the beginning to avoid calling it everytime, within ctx.
+func NewOnlineNetwork(ctx context.Context, network *types.NetworkIdentifier, client crgtypes.ClientV2, opts Options) (crgtypes.API, error) {
+	if err := opts.Validate(); err != nil {
+		return OnlineNetwork{}, err
+	}
+	if err := opts.errorRegistry().Seal(); err != nil {
+		return OnlineNetwork{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, genesisBlockFetchTimeout)
 	defer cancel()
 
+	if err := validateTxHasher(crgtypes.UnwrapClient(client)); err != nil {
+		return OnlineNetwork{}, err
+	}
+	schemas, err := operationSchemasFromClient(crgtypes.UnwrapClient(client))
+	if err != nil {
+		return OnlineNetwork{}, err
+	}
+
 	var genesisHeight int64 = 1
 	block, err := client.BlockByHeight(ctx, &genesisHeight)
 	if err != nil {
 		return OnlineNetwork{}, err
 	}
 
-	return OnlineNetwork{
+	on := OnlineNetwork{
 		client:                 client,
 		network:                network,
-		networkOptions:         networkOptionsFromClient(client),
+		networkOptions:         networkOptionsFromClient(ctx, client, opts.errorRegistry()),
 		genesisBlockIdentifier: block.Block,
-	}, nil
+		operationSchemas:       schemas,
+		opts:                   newOptionsHolder(opts),
+		timeouts:               newTimeoutCounters(),
+		blocks:                 newBlockCache(),
+	}
+	return newTimeoutNetwork(on, on), nil
+}
+
+// Options defines the optional settings of the network adapters
+type Options struct {
+	// GasPrices are used to compute the suggested fee of /construction/metadata,
+	// values are decimals expressed in the smallest unit of the currency.
+	// If empty the on-chain minimum gas prices are used, when available.
+	GasPrices []*types.Amount
+	// GasAdjustment multiplies the simulated gas to obtain the gas limit,
+	// defaults to DefaultGasAdjustment
+	GasAdjustment float64
+	// VerifyRoundTrip makes /construction/payloads and /construction/combine parse back
+	// the transaction built by the client and reject it if its operations differ from the intent
+	VerifyRoundTrip bool
+	// ErrorRegistry holds the errors advertised by /network/options,
+	// defaults to the errors default registry
+	ErrorRegistry *errors.Registry
+	// Debug includes the cause chain of the errors in the rosetta error details
+	Debug bool
+	// DefaultTimeout is the time an endpoint has to complete before errors.ErrTimeout
+	// is returned, zero means no timeout
+	DefaultTimeout time.Duration
+	// Timeouts overrides DefaultTimeout per endpoint, keyed by the endpoint path such as EndpointBlock
+	Timeouts map[string]time.Duration
+	// InlineTransactionsLimit is the maximum number of transactions included in /block responses,
+	// the other ones are listed in OtherTransactions. Zero means no limit.
+	InlineTransactionsLimit int
+}
+
+// errorRegistry returns the configured error registry or the default one
+func (o Options) errorRegistry() *errors.Registry {
+	if o.ErrorRegistry == nil {
+		return errors.DefaultRegistry()
+	}
+	return o.ErrorRegistry
+}
+
+// Validate checks the options are valid
+func (o Options) Validate() error {
+	if o.GasAdjustment < 0 {
+		return fmt.Errorf("invalid gas adjustment: %f", o.GasAdjustment)
+	}
+	if o.InlineTransactionsLimit < 0 {
+		return fmt.Errorf("invalid inline transactions limit: %d", o.InlineTransactionsLimit)
+	}
+	for _, price := range o.GasPrices {
+		if _, err := parseGasPrice(price); err != nil {
+			return err
+		}
+	}
+	return validateTimeouts(o.DefaultTimeout, o.Timeouts)
 }
 
 // OnlineNetwork groups together all the components required for the full rosetta implementation
 type OnlineNetwork struct {
-	client crgtypes.Client // used to query cosmos app + tendermint
+	client crgtypes.ClientV2 // used to query cosmos app + tendermint
 
 	network        *types.NetworkIdentifier      // identifies the network, it's static
 	networkOptions *types.NetworkOptionsResponse // identifies the network options, it's static
 
 	genesisBlockIdentifier *types.BlockIdentifier // identifies genesis block, it's static
+
+	operationSchemas operationSchemas // validates construction operations, nil if the client provides none
+
+	opts *optionsHolder // optional settings, shared by the copies of the network as they can change at runtime
+
+	timeouts timeoutCounters // timed out requests per endpoint, shared by the copies of the network
+
+	blocks *blockCache // blocks scanned by /block/transaction, shared by the copies of the network
+}
+
+// optionsHolder holds the network options, allowing them to be updated atomically at runtime
+type optionsHolder struct {
+	v atomic.Value
+}
+
+func newOptionsHolder(opts Options) *optionsHolder {
+	h := new(optionsHolder)
+	h.v.Store(opts)
+	return h
+}
+
+func (h *optionsHolder) load() Options {
+	return h.v.Load().(Options)
+}
+
+// FlushCaches removes the data cached by the network, such as the blocks kept for /block/transaction
+func (on OnlineNetwork) FlushCaches() {
+	on.blocks.flush()
+}
+
+// UpdateOptions atomically replaces the options of the running network,
+// the error registry cannot be changed at runtime.
+func (on OnlineNetwork) UpdateOptions(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.errorRegistry() != on.opts.load().errorRegistry() {
+		return fmt.Errorf("the error registry cannot be changed at runtime")
+	}
+	on.opts.v.Store(opts)
+	return nil
+}
+
+// capabilities returns the value the optional client capabilities are detected on
+func (on OnlineNetwork) capabilities() interface{} {
+	return crgtypes.UnwrapClient(on.client)
+}
+
+// capability returns the value implementing the optional capability c, nil if the client does not support it
+func (on OnlineNetwork) capability(c crgtypes.Capability) interface{} {
+	return crgtypes.WithCapability(on.capabilities(), c)
+}
+
+// toRosetta converts the error to a rosetta error, the cause chain is included only in debug mode
+func (on OnlineNetwork) toRosetta(err error) *types.Error {
+	if on.opts.load().Debug {
+		return errors.ToRosettaDebug(err)
+	}
+	return errors.ToRosetta(err)
 }
 
 // AccountsCoins - relevant only for UTXO based chain
@@ -67,17 +199,32 @@ func (o OnlineNetwork) AccountCoins(_ context.Context, _ *types.AccountCoinsRequ
 	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
 }
 
+// NetworkOptionsTxHashFormat is the version metadata key advertising the format of transaction hashes
+const NetworkOptionsTxHashFormat = "tx_hash_format"
+
+// NetworkOptionsCapabilities is the version metadata key advertising the optional capabilities of the client
+const NetworkOptionsCapabilities = "capabilities"
+
 // networkOptionsFromClient builds network options given the client
-func networkOptionsFromClient(client crgtypes.Client) *types.NetworkOptionsResponse {
+func networkOptionsFromClient(ctx context.Context, client crgtypes.ClientV2, registry *errors.Registry) *types.NetworkOptionsResponse {
+	capabilities := crgtypes.UnwrapClient(client)
+	hashFormat := crgtypes.TxHashFormatUpperHex
+	if hasher, ok := crgtypes.WithCapability(capabilities, crgtypes.CapabilityTxHash).(crgtypes.TxHasher); ok {
+		hashFormat = hasher.TxHashFormat()
+	}
 	return &types.NetworkOptionsResponse{
 		Version: &types.Version{
 			RosettaVersion: crgtypes.SpecVersion,
-			NodeVersion:    client.Version(),
+			NodeVersion:    client.Version(ctx),
+			Metadata: map[string]interface{}{
+				NetworkOptionsTxHashFormat: hashFormat,
+				NetworkOptionsCapabilities: crgtypes.Capabilities(capabilities),
+			},
 		},
 		Allow: &types.Allow{
-			OperationStatuses:       client.OperationStatuses(),
-			OperationTypes:          client.SupportedOperations(),
-			Errors:                  errors.SealAndListErrors(),
+			OperationStatuses:       client.OperationStatuses(ctx),
+			OperationTypes:          client.SupportedOperations(ctx),
+			Errors:                  registry.List(),
 			HistoricalBalanceLookup: true,
 		},
 	}
@@ -0,0 +1,140 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+)
+
+// roundtrip.go contains the consistency checks run, when enabled, on the transactions
+// built by the client: the operations parsed back from the transaction must match the intent.
+
+// verifyPayloads checks the operations parsed from the unsigned transaction match the requested ones
+func (on OnlineNetwork) verifyPayloads(ctx context.Context, request *types.ConstructionPayloadsRequest, resp *types.ConstructionPayloadsResponse) error {
+	txBytes, err := hex.DecodeString(resp.UnsignedTransaction)
+	if err != nil {
+		return crgerrs.WrapError(crgerrs.ErrInvalidTransaction, fmt.Sprintf("client returned an invalid unsigned transaction: %s", err))
+	}
+	parsed, _, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, false, txBytes)
+	if err != nil {
+		return err
+	}
+	return compareOperations(request.Operations, parsed)
+}
+
+// verifyCombine checks the operations of the signed transaction match the unsigned one
+// and that every signature belongs to one of the transaction signers
+func (on OnlineNetwork) verifyCombine(ctx context.Context, unsignedTx, signedTx []byte, sigs []*types.Signature) error {
+	expected, _, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, false, unsignedTx)
+	if err != nil {
+		return err
+	}
+	parsed, signers, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, true, signedTx)
+	if err != nil {
+		return err
+	}
+	if err := compareOperations(expected, parsed); err != nil {
+		return err
+	}
+
+	signerSet := make(map[string]struct{}, len(signers))
+	for _, signer := range signers {
+		signerSet[accountKey(signer)] = struct{}{}
+	}
+	for i, sig := range sigs {
+		if sig.SigningPayload == nil {
+			continue
+		}
+		account := sig.SigningPayload.AccountIdentifier
+		if account == nil {
+			continue
+		}
+		if _, ok := signerSet[accountKey(account)]; !ok {
+			return crgerrs.WrapError(crgerrs.ErrInvalidTransaction,
+				fmt.Sprintf("round trip mismatch: signatures[%d] account %s is not a signer of the transaction", i, account.Address))
+		}
+	}
+	return nil
+}
+
+// compareOperations checks the two operation lists are equal, ignoring order, identifiers and status
+func compareOperations(expected, parsed []*types.Operation) error {
+	counts := make(map[string]int, len(expected))
+	for _, op := range expected {
+		counts[operationKey(op)]++
+	}
+	for _, op := range parsed {
+		counts[operationKey(op)]--
+	}
+
+	var missing, unexpected []string
+	for key, count := range counts {
+		for ; count > 0; count-- {
+			missing = append(missing, key)
+		}
+		for ; count < 0; count++ {
+			unexpected = append(unexpected, key)
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	diff := make([]string, 0, len(missing)+len(unexpected))
+	for _, key := range missing {
+		diff = append(diff, "- "+key)
+	}
+	for _, key := range unexpected {
+		diff = append(diff, "+ "+key)
+	}
+	return crgerrs.WrapError(crgerrs.ErrInvalidTransaction,
+		fmt.Sprintf("round trip mismatch between requested (-) and parsed (+) operations: %s", strings.Join(diff, "; ")))
+}
+
+// operationKey returns the canonical representation of an operation used for comparisons
+func operationKey(op *types.Operation) string {
+	key := fmt.Sprintf("type=%s account=%s", op.Type, accountKey(op.Account))
+	if op.Amount != nil {
+		key += " amount=" + op.Amount.Value
+		if op.Amount.Currency != nil {
+			key += fmt.Sprintf(" %s/%d", op.Amount.Currency.Symbol, op.Amount.Currency.Decimals)
+		}
+	}
+	return key
+}
+
+// accountKey returns the canonical representation of an account identifier
+func accountKey(account *types.AccountIdentifier) string {
+	if account == nil {
+		return "<nil>"
+	}
+	if account.SubAccount == nil {
+		return account.Address
+	}
+	return account.Address + "/" + account.SubAccount.Address
+}
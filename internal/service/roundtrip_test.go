@@ -0,0 +1,160 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+func TestCompareOperations(t *testing.T) {
+	atom := &types.Currency{Symbol: "uatom", Decimals: 6}
+	op := func(index int64, opType, address, value string) *types.Operation {
+		return &types.Operation{
+			OperationIdentifier: &types.OperationIdentifier{Index: index},
+			Type:                opType,
+			Account:             &types.AccountIdentifier{Address: address},
+			Amount:              &types.Amount{Value: value, Currency: atom},
+		}
+	}
+	success := "success"
+	withStatus := op(0, "transfer", "alice", "-10")
+	withStatus.Status = &success
+
+	tests := []struct {
+		name     string
+		expected []*types.Operation
+		parsed   []*types.Operation
+		// wantDiff lists the substrings of the expected mismatch, nil if the operations match
+		wantDiff []string
+	}{
+		{
+			name:     "equal",
+			expected: []*types.Operation{op(0, "transfer", "alice", "-10"), op(1, "transfer", "bob", "10")},
+			parsed:   []*types.Operation{op(0, "transfer", "alice", "-10"), op(1, "transfer", "bob", "10")},
+		},
+		{
+			name:     "order, identifiers and status are ignored",
+			expected: []*types.Operation{op(0, "transfer", "alice", "-10"), op(1, "transfer", "bob", "10")},
+			parsed:   []*types.Operation{op(5, "transfer", "bob", "10"), withStatus},
+		},
+		{
+			name:     "amount differs",
+			expected: []*types.Operation{op(0, "transfer", "bob", "10")},
+			parsed:   []*types.Operation{op(0, "transfer", "bob", "11")},
+			wantDiff: []string{"- type=transfer account=bob amount=10 uatom/6", "+ type=transfer account=bob amount=11 uatom/6"},
+		},
+		{
+			name:     "missing operation",
+			expected: []*types.Operation{op(0, "transfer", "alice", "-10"), op(1, "transfer", "bob", "10")},
+			parsed:   []*types.Operation{op(0, "transfer", "alice", "-10")},
+			wantDiff: []string{"- type=transfer account=bob"},
+		},
+		{
+			name:     "duplicated operation",
+			expected: []*types.Operation{op(0, "transfer", "bob", "10")},
+			parsed:   []*types.Operation{op(0, "transfer", "bob", "10"), op(1, "transfer", "bob", "10")},
+			wantDiff: []string{"+ type=transfer account=bob"},
+		},
+		{
+			name:     "sub account differs",
+			expected: []*types.Operation{{Type: "stake", Account: &types.AccountIdentifier{Address: "alice", SubAccount: &types.SubAccountIdentifier{Address: "val1"}}}},
+			parsed:   []*types.Operation{{Type: "stake", Account: &types.AccountIdentifier{Address: "alice", SubAccount: &types.SubAccountIdentifier{Address: "val2"}}}},
+			wantDiff: []string{"- type=stake account=alice/val1", "+ type=stake account=alice/val2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compareOperations(tt.expected, tt.parsed)
+			if tt.wantDiff == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected a mismatch")
+			}
+			for _, diff := range tt.wantDiff {
+				if !strings.Contains(errorInfo(err), diff) {
+					t.Fatalf("expected %q in %q", diff, errorInfo(err))
+				}
+			}
+		})
+	}
+}
+
+// parserClient decodes the transactions from a fixed table, keyed by the transaction bytes
+type parserClient struct {
+	crgtypes.ClientV2
+	ops     map[string][]*types.Operation
+	signers []*types.AccountIdentifier
+}
+
+func (c parserClient) TxOperationsAndSignersAccountIdentifiers(_ context.Context, signed bool, txBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	if !signed {
+		return c.ops[string(txBytes)], nil, nil
+	}
+	return c.ops[string(txBytes)], c.signers, nil
+}
+
+func TestVerifyCombine(t *testing.T) {
+	transfer := []*types.Operation{
+		{Type: "transfer", Account: &types.AccountIdentifier{Address: "alice"}, Amount: &types.Amount{Value: "-10"}},
+		{Type: "transfer", Account: &types.AccountIdentifier{Address: "bob"}, Amount: &types.Amount{Value: "10"}},
+	}
+	tampered := []*types.Operation{
+		{Type: "transfer", Account: &types.AccountIdentifier{Address: "alice"}, Amount: &types.Amount{Value: "-20"}},
+		{Type: "transfer", Account: &types.AccountIdentifier{Address: "bob"}, Amount: &types.Amount{Value: "20"}},
+	}
+	signature := func(address string) *types.Signature {
+		return &types.Signature{SigningPayload: &types.SigningPayload{AccountIdentifier: &types.AccountIdentifier{Address: address}}}
+	}
+	on := OnlineNetwork{client: parserClient{
+		ops:     map[string][]*types.Operation{"unsigned": transfer, "signed": transfer, "tampered": tampered},
+		signers: []*types.AccountIdentifier{{Address: "alice"}},
+	}}
+
+	tests := []struct {
+		name     string
+		signedTx string
+		sigs     []*types.Signature
+		wantInfo string
+	}{
+		{name: "valid", signedTx: "signed", sigs: []*types.Signature{signature("alice")}},
+		{name: "signature without account", signedTx: "signed", sigs: []*types.Signature{{}}},
+		{name: "operations differ", signedTx: "tampered", sigs: []*types.Signature{signature("alice")}, wantInfo: "amount=-20"},
+		{name: "not a signer", signedTx: "signed", sigs: []*types.Signature{signature("alice"), signature("bob")}, wantInfo: "signatures[1] account bob is not a signer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := on.verifyCombine(context.Background(), []byte("unsigned"), []byte(tt.signedTx), tt.sigs)
+			switch {
+			case tt.wantInfo == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantInfo != "" && !strings.Contains(errorInfo(err), tt.wantInfo):
+				t.Fatalf("expected error containing %q, got %q", tt.wantInfo, errorInfo(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// submitHash is the hash of the transactions broadcast by submitClient
+const submitHash = "ABCD"
+
+// submitClient is a clienttest client accepting any transaction, its inclusion wait
+// returns result or blocks until the context is done if result is nil
+type submitClient struct {
+	*clienttest.Client
+	posts  int
+	result *crgtypes.TxInclusionResult
+}
+
+func (c *submitClient) PostTx(context.Context, []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	c.posts++
+	return &types.TransactionIdentifier{Hash: submitHash}, nil, nil
+}
+
+func (c *submitClient) WaitTxInclusion(ctx context.Context, _ string) (*crgtypes.TxInclusionResult, error) {
+	if c.result != nil {
+		return c.result, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// noWaitClient is a submitClient without TxInclusionWaiter
+type noWaitClient struct {
+	crgtypes.Client
+}
+
+func TestConstructionSubmitInclusion(t *testing.T) {
+	block := &types.BlockIdentifier{Index: 2, Hash: "block"}
+	result := &crgtypes.TxInclusionResult{Block: block, Code: 5, Codespace: "sdk", GasWanted: 2, GasUsed: 1, Log: "log"}
+
+	tests := []struct {
+		name            string
+		meta            map[string]interface{}
+		result          *crgtypes.TxInclusionResult
+		noWaiter        bool
+		endpointTimeout time.Duration
+		wantErr         *crgerrs.Error
+		wantMeta        map[string]interface{}
+	}{
+		{name: "no wait", meta: nil, wantMeta: map[string]interface{}{}},
+		{
+			name:   "included",
+			meta:   map[string]interface{}{service.SubmitWaitForInclusion: true},
+			result: result,
+			wantMeta: map[string]interface{}{
+				service.SubmitMetaIncluded:        true,
+				service.SubmitMetaBlockIdentifier: block,
+				service.SubmitMetaCode:            uint32(5),
+				service.SubmitMetaCodespace:       "sdk",
+				service.SubmitMetaGasWanted:       int64(2),
+				service.SubmitMetaGasUsed:         int64(1),
+				service.SubmitMetaLog:             "log",
+			},
+		},
+		{
+			name:     "inclusion timeout",
+			meta:     map[string]interface{}{service.SubmitWaitForInclusion: true, service.SubmitInclusionTimeout: "10ms"},
+			wantMeta: map[string]interface{}{service.SubmitMetaIncluded: false},
+		},
+		{
+			name:            "endpoint timeout shorter than the inclusion timeout",
+			meta:            map[string]interface{}{service.SubmitWaitForInclusion: true, service.SubmitInclusionTimeout: "1m"},
+			endpointTimeout: 500 * time.Millisecond,
+			wantMeta:        map[string]interface{}{service.SubmitMetaIncluded: false},
+		},
+		{
+			name:     "not supported",
+			meta:     map[string]interface{}{service.SubmitWaitForInclusion: true},
+			noWaiter: true,
+			wantErr:  crgerrs.ErrNotImplemented,
+		},
+		{
+			name:    "invalid metadata",
+			meta:    map[string]interface{}{service.SubmitWaitForInclusion: "yes"},
+			wantErr: crgerrs.ErrBadArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &submitClient{Client: clienttest.NewClient(clienttest.Config{}), result: tt.result}
+			client := crgtypes.AdaptClient(c)
+			if tt.noWaiter {
+				client = crgtypes.AdaptClient(noWaitClient{c})
+			}
+			opts := service.Options{Timeouts: map[string]time.Duration{service.EndpointConstructionSubmit: tt.endpointTimeout}}
+			network, err := service.NewOnlineNetwork(context.Background(), c.Network(), client, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := service.WithRequestMetadata(context.Background(), tt.meta)
+			resp, rosErr := network.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+				NetworkIdentifier: c.Network(),
+				SignedTransaction: "00",
+			})
+			if tt.wantErr != nil {
+				if rosErr == nil || rosErr.Code != crgerrs.ToRosetta(tt.wantErr).Code {
+					t.Fatalf("expected error %d, got %+v", crgerrs.ToRosetta(tt.wantErr).Code, rosErr)
+				}
+				if c.posts != 0 {
+					t.Fatal("expected the transaction not to be broadcast")
+				}
+				return
+			}
+			if rosErr != nil {
+				t.Fatalf("unexpected error: %s", rosErr.Message)
+			}
+			if resp.TransactionIdentifier.Hash != submitHash {
+				t.Fatalf("expected tx %s, got %s", submitHash, resp.TransactionIdentifier.Hash)
+			}
+			if len(resp.Metadata) != len(tt.wantMeta) {
+				t.Fatalf("expected metadata %v, got %v", tt.wantMeta, resp.Metadata)
+			}
+			for k, v := range tt.wantMeta {
+				if resp.Metadata[k] != v {
+					t.Fatalf("expected metadata %s %v, got %v", k, v, resp.Metadata[k])
+				}
+			}
+		})
+	}
+}
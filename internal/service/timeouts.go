@@ -0,0 +1,350 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// timeouts.go applies the per endpoint deadlines, an endpoint which does not complete
+// in time returns the retriable errors.ErrTimeout even if the client ignores the context.
+
+// Endpoints of the rosetta API, used as keys of Options.Timeouts
+const (
+	EndpointNetworkList            = "/network/list"
+	EndpointNetworkOptions         = "/network/options"
+	EndpointNetworkStatus          = "/network/status"
+	EndpointAccountBalance         = "/account/balance"
+	EndpointAccountCoins           = "/account/coins"
+	EndpointBlock                  = "/block"
+	EndpointBlockTransaction       = "/block/transaction"
+	EndpointMempool                = "/mempool"
+	EndpointMempoolTransaction     = "/mempool/transaction"
+	EndpointConstructionCombine    = "/construction/combine"
+	EndpointConstructionDerive     = "/construction/derive"
+	EndpointConstructionHash       = "/construction/hash"
+	EndpointConstructionMetadata   = "/construction/metadata"
+	EndpointConstructionParse      = "/construction/parse"
+	EndpointConstructionPayloads   = "/construction/payloads"
+	EndpointConstructionPreprocess = "/construction/preprocess"
+	EndpointConstructionSubmit     = "/construction/submit"
+)
+
+// Endpoints lists the endpoints which can be given a timeout
+var Endpoints = []string{
+	EndpointNetworkList,
+	EndpointNetworkOptions,
+	EndpointNetworkStatus,
+	EndpointAccountBalance,
+	EndpointAccountCoins,
+	EndpointBlock,
+	EndpointBlockTransaction,
+	EndpointMempool,
+	EndpointMempoolTransaction,
+	EndpointConstructionCombine,
+	EndpointConstructionDerive,
+	EndpointConstructionHash,
+	EndpointConstructionMetadata,
+	EndpointConstructionParse,
+	EndpointConstructionPayloads,
+	EndpointConstructionPreprocess,
+	EndpointConstructionSubmit,
+}
+
+// validateTimeouts checks the endpoint timeouts refer to known endpoints and are not negative
+func validateTimeouts(defaultTimeout time.Duration, timeouts map[string]time.Duration) error {
+	if defaultTimeout < 0 {
+		return fmt.Errorf("invalid default timeout: %s", defaultTimeout)
+	}
+	for endpoint, timeout := range timeouts {
+		if !isEndpoint(endpoint) {
+			return fmt.Errorf("invalid timeout: unknown endpoint %s", endpoint)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("invalid timeout of %s: %s", endpoint, timeout)
+		}
+	}
+	return nil
+}
+
+func isEndpoint(endpoint string) bool {
+	for _, e := range Endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// timeout returns the timeout of the endpoint, zero means no timeout
+func (o Options) timeout(endpoint string) time.Duration {
+	if timeout, ok := o.Timeouts[endpoint]; ok {
+		return timeout
+	}
+	return o.DefaultTimeout
+}
+
+// timeoutCounters counts the timed out requests per endpoint,
+// the map is never modified after creation so it can be read concurrently
+type timeoutCounters map[string]*uint64
+
+func newTimeoutCounters() timeoutCounters {
+	c := make(timeoutCounters, len(Endpoints))
+	for _, endpoint := range Endpoints {
+		c[endpoint] = new(uint64)
+	}
+	return c
+}
+
+// TimeoutCounts returns the number of timed out requests per endpoint since the network was built
+func (on OnlineNetwork) TimeoutCounts() map[string]uint64 {
+	counts := make(map[string]uint64, len(on.timeouts))
+	for endpoint, n := range on.timeouts {
+		counts[endpoint] = atomic.LoadUint64(n)
+	}
+	return counts
+}
+
+// Panic is the value raised again in the request goroutine when an endpoint call run with
+// a timeout panics, it carries the stack of the goroutine which panicked
+type Panic struct {
+	// Value is the value the call panicked with
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (p *Panic) String() string {
+	return fmt.Sprint(p.Value)
+}
+
+// callResult is the outcome of an endpoint call run by withTimeout
+type callResult struct {
+	resp  interface{}
+	err   *types.Error
+	panic *Panic
+}
+
+// withTimeout runs call with the endpoint deadline, if the deadline expires before call returns
+// errors.ErrTimeout is returned and call is left to complete in the background
+func (on OnlineNetwork) withTimeout(ctx context.Context, endpoint string, call func(ctx context.Context) (interface{}, *types.Error)) (interface{}, *types.Error) {
+	timeout := on.opts.load().timeout(endpoint)
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan callResult, 1)
+	go func() {
+		var res callResult
+		defer func() {
+			// panics are raised again in the request goroutine, where they can be recovered,
+			// the stack is captured here as the one of the request goroutine does not show the call
+			if rec := recover(); rec != nil {
+				res.panic = &Panic{Value: rec, Stack: debug.Stack()}
+			}
+			done <- res
+		}()
+		res.resp, res.err = call(callCtx)
+	}()
+
+	select {
+	case res := <-done:
+		if res.panic != nil {
+			panic(res.panic)
+		}
+		// a client honouring the deadline returns its own error, which is reported as a timeout
+		if res.err == nil || !timedOut(ctx, callCtx) {
+			return res.resp, res.err
+		}
+	case <-callCtx.Done():
+		// the request was canceled or its own deadline expired
+		if !timedOut(ctx, callCtx) {
+			if ctx.Err() == context.Canceled {
+				return nil, on.toRosetta(errors.Wrap(errors.ErrCanceled, ctx.Err()))
+			}
+			return nil, on.toRosetta(errors.Wrap(errors.ErrTimeout, ctx.Err()))
+		}
+	}
+
+	atomic.AddUint64(on.timeouts[endpoint], 1)
+	return nil, on.toRosetta(errors.WrapError(errors.ErrTimeout, fmt.Sprintf("%s did not complete within %s", endpoint, timeout)))
+}
+
+// timedOut reports if the endpoint deadline of callCtx expired, as opposed to the request being canceled
+func timedOut(ctx, callCtx context.Context) bool {
+	return callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+}
+
+// timeoutNetwork applies the endpoint timeouts to the calls of the wrapped network
+type timeoutNetwork struct {
+	OnlineNetwork
+	api crgtypes.API
+}
+
+// newTimeoutNetwork wraps api, which is either on or an offline network embedding it
+func newTimeoutNetwork(on OnlineNetwork, api crgtypes.API) timeoutNetwork {
+	return timeoutNetwork{OnlineNetwork: on, api: api}
+}
+
+func (n timeoutNetwork) NetworkList(ctx context.Context, request *types.MetadataRequest) (*types.NetworkListResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointNetworkList, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.NetworkList(ctx, request)
+	})
+	r, _ := resp.(*types.NetworkListResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) NetworkOptions(ctx context.Context, request *types.NetworkRequest) (*types.NetworkOptionsResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointNetworkOptions, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.NetworkOptions(ctx, request)
+	})
+	r, _ := resp.(*types.NetworkOptionsResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) NetworkStatus(ctx context.Context, request *types.NetworkRequest) (*types.NetworkStatusResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointNetworkStatus, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.NetworkStatus(ctx, request)
+	})
+	r, _ := resp.(*types.NetworkStatusResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) AccountBalance(ctx context.Context, request *types.AccountBalanceRequest) (*types.AccountBalanceResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointAccountBalance, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.AccountBalance(ctx, request)
+	})
+	r, _ := resp.(*types.AccountBalanceResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) AccountCoins(ctx context.Context, request *types.AccountCoinsRequest) (*types.AccountCoinsResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointAccountCoins, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.AccountCoins(ctx, request)
+	})
+	r, _ := resp.(*types.AccountCoinsResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) Block(ctx context.Context, request *types.BlockRequest) (*types.BlockResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointBlock, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.Block(ctx, request)
+	})
+	r, _ := resp.(*types.BlockResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) BlockTransaction(ctx context.Context, request *types.BlockTransactionRequest) (*types.BlockTransactionResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointBlockTransaction, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.BlockTransaction(ctx, request)
+	})
+	r, _ := resp.(*types.BlockTransactionResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) Mempool(ctx context.Context, request *types.NetworkRequest) (*types.MempoolResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointMempool, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.Mempool(ctx, request)
+	})
+	r, _ := resp.(*types.MempoolResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) MempoolTransaction(ctx context.Context, request *types.MempoolTransactionRequest) (*types.MempoolTransactionResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointMempoolTransaction, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.MempoolTransaction(ctx, request)
+	})
+	r, _ := resp.(*types.MempoolTransactionResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionCombine(ctx context.Context, request *types.ConstructionCombineRequest) (*types.ConstructionCombineResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionCombine, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionCombine(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionCombineResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionDerive(ctx context.Context, request *types.ConstructionDeriveRequest) (*types.ConstructionDeriveResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionDerive, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionDerive(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionDeriveResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionHash(ctx context.Context, request *types.ConstructionHashRequest) (*types.TransactionIdentifierResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionHash, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionHash(ctx, request)
+	})
+	r, _ := resp.(*types.TransactionIdentifierResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionMetadata(ctx context.Context, request *types.ConstructionMetadataRequest) (*types.ConstructionMetadataResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionMetadata, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionMetadata(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionMetadataResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionParse(ctx context.Context, request *types.ConstructionParseRequest) (*types.ConstructionParseResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionParse, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionParse(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionParseResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionPayloads(ctx context.Context, request *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionPayloads, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionPayloads(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionPayloadsResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionPreprocess(ctx context.Context, request *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionPreprocess, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionPreprocess(ctx, request)
+	})
+	r, _ := resp.(*types.ConstructionPreprocessResponse)
+	return r, err
+}
+
+func (n timeoutNetwork) ConstructionSubmit(ctx context.Context, request *types.ConstructionSubmitRequest) (*types.TransactionIdentifierResponse, *types.Error) {
+	resp, err := n.withTimeout(ctx, EndpointConstructionSubmit, func(ctx context.Context) (interface{}, *types.Error) {
+		return n.api.ConstructionSubmit(ctx, request)
+	})
+	r, _ := resp.(*types.TransactionIdentifierResponse)
+	return r, err
+}
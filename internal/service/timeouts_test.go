@@ -0,0 +1,131 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+)
+
+// timeoutTestNetwork returns a network applying timeout to every endpoint
+func timeoutTestNetwork(timeout time.Duration) OnlineNetwork {
+	return OnlineNetwork{
+		opts:     newOptionsHolder(Options{DefaultTimeout: timeout}),
+		timeouts: newTimeoutCounters(),
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	// release unblocks the calls ignoring their context once the test is done
+	release := make(chan struct{})
+	defer close(release)
+
+	ok := func(context.Context) (interface{}, *types.Error) { return "ok", nil }
+	ignoreContext := func(context.Context) (interface{}, *types.Error) {
+		<-release
+		return "late", nil
+	}
+	honourContext := func(ctx context.Context) (interface{}, *types.Error) {
+		<-ctx.Done()
+		return nil, crgerrs.ToRosetta(crgerrs.Wrap(crgerrs.ErrInternal, ctx.Err()))
+	}
+	canceled := func(*testing.T) context.Context {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx
+	}
+	withDeadline := func(t *testing.T) context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		ctx       func(t *testing.T) context.Context
+		call      func(context.Context) (interface{}, *types.Error)
+		wantResp  interface{}
+		wantErr   *crgerrs.Error
+		wantCount uint64
+	}{
+		{name: "no timeout", call: ok, wantResp: "ok"},
+		{name: "completes in time", timeout: time.Second, call: ok, wantResp: "ok"},
+		{name: "client ignores the deadline", timeout: 10 * time.Millisecond, call: ignoreContext, wantErr: crgerrs.ErrTimeout, wantCount: 1},
+		{name: "client honours the deadline", timeout: 10 * time.Millisecond, call: honourContext, wantErr: crgerrs.ErrTimeout, wantCount: 1},
+		{name: "request canceled", timeout: time.Hour, ctx: canceled, call: ignoreContext, wantErr: crgerrs.ErrCanceled},
+		{name: "request deadline", timeout: time.Hour, ctx: withDeadline, call: ignoreContext, wantErr: crgerrs.ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			on := timeoutTestNetwork(tt.timeout)
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx(t)
+			}
+			resp, err := on.withTimeout(ctx, EndpointBlock, tt.call)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected error: %s", err.Message)
+			case tt.wantErr != nil && (err == nil || err.Code != crgerrs.ToRosetta(tt.wantErr).Code):
+				t.Fatalf("expected error %s, got %v", tt.wantErr.Error(), err)
+			case tt.wantErr == nil && resp != tt.wantResp:
+				t.Fatalf("expected response %v, got %v", tt.wantResp, resp)
+			}
+			if got := on.TimeoutCounts()[EndpointBlock]; got != tt.wantCount {
+				t.Fatalf("expected %d timed out requests, got %d", tt.wantCount, got)
+			}
+		})
+	}
+}
+
+// panickingCall is the endpoint call panicking in TestWithTimeoutPanic, its name is looked up in the stack
+func panickingCall(context.Context) (interface{}, *types.Error) {
+	panic("client panic")
+}
+
+func TestWithTimeoutPanic(t *testing.T) {
+	on := timeoutTestNetwork(time.Second)
+	var calls int32
+	defer func() {
+		p, ok := recover().(*Panic)
+		if !ok {
+			t.Fatal("expected the call panic to be raised again as a *Panic")
+		}
+		if p.Value != "client panic" {
+			t.Errorf("unexpected panic value %v", p.Value)
+		}
+		if !strings.Contains(string(p.Stack), "panickingCall") {
+			t.Errorf("expected the stack of the panicking call, got\n%s", p.Stack)
+		}
+		if atomic.LoadInt32(&calls) != 1 {
+			t.Errorf("expected one call, got %d", calls)
+		}
+	}()
+	_, _ = on.withTimeout(context.Background(), EndpointBlock, func(ctx context.Context) (interface{}, *types.Error) {
+		atomic.AddInt32(&calls, 1)
+		return panickingCall(ctx)
+	})
+}
@@ -0,0 +1,184 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// currencyKey identifies a currency when used as map key
+type currencyKey struct {
+	Symbol   string
+	Decimals int32
+}
+
+// operationSchemas indexes the operation schemas by operation type
+type operationSchemas map[string]*crgtypes.OperationSchema
+
+// operationSchemasFromClient returns the operation schemas of the client,
+// nil is returned if the client does not provide them
+func operationSchemasFromClient(client interface{}) (operationSchemas, error) {
+	provider, ok := crgtypes.WithCapability(client, crgtypes.CapabilityOperationSchemas).(crgtypes.OperationSchemaProvider)
+	if !ok {
+		return nil, nil
+	}
+	schemas := make(operationSchemas)
+	for _, schema := range provider.OperationSchemas() {
+		if _, exists := schemas[schema.Type]; exists {
+			return nil, fmt.Errorf("duplicate operation schema for type %s", schema.Type)
+		}
+		schemas[schema.Type] = schema
+	}
+	return schemas, nil
+}
+
+// validate checks the operations against the schemas, a nil schema set accepts everything
+func (s operationSchemas) validate(ops []*types.Operation) error {
+	if s == nil {
+		return nil
+	}
+
+	// balances tracks the sum of amounts per operation type and currency of balanced operations,
+	// in the order they first appear so that the reported unbalanced operations are deterministic
+	var balances []*operationsBalance
+	for i, op := range ops {
+		if op == nil {
+			return invalidOperation(i, "operation is nil")
+		}
+		schema, ok := s[op.Type]
+		if !ok {
+			return invalidOperation(i, "unsupported operation type %s", op.Type)
+		}
+
+		if schema.RequireAccount && (op.Account == nil || op.Account.Address == "") {
+			return invalidOperation(i, "account is required for operation type %s", op.Type)
+		}
+
+		for _, key := range schema.RequiredMetadata {
+			if _, ok := op.Metadata[key]; !ok {
+				return invalidOperation(i, "missing metadata key %s", key)
+			}
+		}
+
+		value, err := validateAmount(schema, op.Amount)
+		if err != nil {
+			return invalidOperation(i, "%s", err)
+		}
+
+		if !schema.Balanced || value == nil {
+			continue
+		}
+		currency := currencyKey{Symbol: op.Amount.Currency.Symbol, Decimals: op.Amount.Currency.Decimals}
+		balance := findBalance(balances, op.Type, currency)
+		if balance == nil {
+			balance = &operationsBalance{opType: op.Type, currency: currency, sum: new(big.Int)}
+			balances = append(balances, balance)
+		}
+		balance.sum.Add(balance.sum, value)
+		balance.indexes = append(balance.indexes, i)
+	}
+
+	for _, balance := range balances {
+		if balance.sum.Sign() != 0 {
+			return crgerrs.WrapError(crgerrs.ErrInvalidOperation,
+				fmt.Sprintf("operations%v of type %s are unbalanced: %s amounts sum up to %s", balance.indexes, balance.opType, balance.currency.Symbol, balance.sum)).
+				WithDetail(crgerrs.DetailsOperationIndexes, balance.indexes)
+		}
+	}
+
+	return nil
+}
+
+// operationsBalance is the sum of the amounts of the balanced operations of a type in a currency
+type operationsBalance struct {
+	opType   string
+	currency currencyKey
+	sum      *big.Int
+	// indexes are the indexes of the operations summed up
+	indexes []int
+}
+
+// findBalance returns the balance of the operation type and currency, nil if not tracked yet
+func findBalance(balances []*operationsBalance, opType string, currency currencyKey) *operationsBalance {
+	for _, balance := range balances {
+		if balance.opType == opType && balance.currency == currency {
+			return balance
+		}
+	}
+	return nil
+}
+
+// validateAmount checks the amount against the schema and returns its value, nil if the amount is not set
+func validateAmount(schema *crgtypes.OperationSchema, amount *types.Amount) (*big.Int, error) {
+	if amount == nil {
+		switch schema.Amount {
+		case crgtypes.AmountOptional, crgtypes.AmountForbidden:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("amount is required for operation type %s", schema.Type)
+		}
+	}
+
+	if schema.Amount == crgtypes.AmountForbidden {
+		return nil, fmt.Errorf("amount is not allowed for operation type %s", schema.Type)
+	}
+
+	if amount.Currency == nil {
+		return nil, fmt.Errorf("amount currency is required")
+	}
+	if len(schema.Currencies) != 0 && !currencyAllowed(schema.Currencies, amount.Currency) {
+		return nil, fmt.Errorf("unsupported currency %s with %d decimals", amount.Currency.Symbol, amount.Currency.Decimals)
+	}
+
+	value, ok := new(big.Int).SetString(amount.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount value %q", amount.Value)
+	}
+
+	switch {
+	case schema.Amount == crgtypes.AmountPositive && value.Sign() <= 0:
+		return nil, fmt.Errorf("amount must be positive, got %s", amount.Value)
+	case schema.Amount == crgtypes.AmountNegative && value.Sign() >= 0:
+		return nil, fmt.Errorf("amount must be negative, got %s", amount.Value)
+	}
+
+	return value, nil
+}
+
+// currencyAllowed checks if the currency symbol and decimals match one of the allowed currencies
+func currencyAllowed(allowed []*types.Currency, currency *types.Currency) bool {
+	for _, c := range allowed {
+		if c.Symbol == currency.Symbol && c.Decimals == currency.Decimals {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidOperation returns an ErrInvalidOperation pointing to the operation at the given index,
+// which is also set as the DetailsOperationIndex detail
+func invalidOperation(index int, format string, args ...interface{}) error {
+	return crgerrs.WrapError(crgerrs.ErrInvalidOperation, fmt.Sprintf("operations[%d]: %s", index, fmt.Sprintf(format, args...))).
+		WithDetail(crgerrs.DetailsOperationIndex, index)
+}
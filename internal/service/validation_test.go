@@ -0,0 +1,166 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package service
+
+import (
+	stderrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+type schemaProvider []*crgtypes.OperationSchema
+
+func (p schemaProvider) OperationSchemas() []*crgtypes.OperationSchema { return p }
+
+func TestOperationSchemasFromClient(t *testing.T) {
+	schemas, err := operationSchemasFromClient(struct{}{})
+	if err != nil || schemas != nil {
+		t.Fatalf("client without schemas: got %v, %v", schemas, err)
+	}
+
+	_, err = operationSchemasFromClient(schemaProvider{{Type: "transfer"}, {Type: "transfer"}})
+	if err == nil {
+		t.Fatal("expected duplicate schema error")
+	}
+}
+
+func TestOperationSchemasValidate(t *testing.T) {
+	atom := &types.Currency{Symbol: "uatom", Decimals: 6}
+	schemas, err := operationSchemasFromClient(schemaProvider{
+		{
+			Type:           "transfer",
+			RequireAccount: true,
+			Amount:         crgtypes.AmountAny,
+			Currencies:     []*types.Currency{atom},
+			Balanced:       true,
+		},
+		{Type: "fee", RequireAccount: true, Amount: crgtypes.AmountNegative},
+		{Type: "delegate", Amount: crgtypes.AmountPositive, RequiredMetadata: []string{"validator"}},
+		{Type: "withdraw", Amount: crgtypes.AmountForbidden},
+		{Type: "memo", Amount: crgtypes.AmountOptional},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := &types.AccountIdentifier{Address: "addr"}
+	op := func(opType, value string, currency *types.Currency) *types.Operation {
+		o := &types.Operation{Type: opType, Account: account}
+		if value != "" {
+			o.Amount = &types.Amount{Value: value, Currency: currency}
+		}
+		return o
+	}
+
+	tests := []struct {
+		name string
+		ops  []*types.Operation
+		// wantErr is a substring of the expected error, empty if the operations are valid
+		wantErr string
+	}{
+		{"balanced transfer", []*types.Operation{op("transfer", "-10", atom), op("transfer", "10", atom)}, ""},
+		{"unbalanced transfer", []*types.Operation{op("transfer", "-10", atom), op("transfer", "9", atom)}, "unbalanced"},
+		{"unsupported type", []*types.Operation{op("burn", "1", atom)}, "operations[0]: unsupported operation type burn"},
+		{"missing account", []*types.Operation{{Type: "fee", Amount: &types.Amount{Value: "-1", Currency: atom}}}, "account is required"},
+		{"unsupported currency", []*types.Operation{op("transfer", "-1", &types.Currency{Symbol: "uatom", Decimals: 0}), op("transfer", "1", atom)}, "unsupported currency"},
+		{"missing currency", []*types.Operation{op("transfer", "1", nil)}, "currency is required"},
+		{"invalid value", []*types.Operation{op("transfer", "1.5", atom)}, "invalid amount value"},
+		{"negative fee", []*types.Operation{op("fee", "-1", atom)}, ""},
+		{"positive fee", []*types.Operation{op("fee", "1", atom)}, "must be negative"},
+		{"missing fee amount", []*types.Operation{op("fee", "", nil)}, "amount is required"},
+		{"positive delegate", []*types.Operation{{Type: "delegate", Amount: &types.Amount{Value: "5", Currency: atom}, Metadata: map[string]interface{}{"validator": "val"}}}, ""},
+		{"zero delegate", []*types.Operation{{Type: "delegate", Amount: &types.Amount{Value: "0", Currency: atom}, Metadata: map[string]interface{}{"validator": "val"}}}, "must be positive"},
+		{"missing metadata", []*types.Operation{{Type: "delegate", Amount: &types.Amount{Value: "5", Currency: atom}}}, "missing metadata key validator"},
+		{"forbidden amount", []*types.Operation{op("withdraw", "1", atom)}, "amount is not allowed"},
+		{"no amount", []*types.Operation{op("withdraw", "", nil), op("memo", "", nil)}, ""},
+		{"error index", []*types.Operation{op("memo", "", nil), op("withdraw", "1", atom)}, "operations[1]"},
+		{"nil operation", []*types.Operation{op("memo", "", nil), nil}, "operations[1]: operation is nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schemas.validate(tt.ops)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(errorInfo(err), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !stderrors.Is(err, crgerrs.ErrInvalidOperation) {
+				t.Fatalf("expected ErrInvalidOperation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOperationSchemasValidateDetails(t *testing.T) {
+	atom := &types.Currency{Symbol: "uatom", Decimals: 6}
+	schemas, err := operationSchemasFromClient(schemaProvider{
+		{Type: "transfer", Amount: crgtypes.AmountAny, Balanced: true},
+		{Type: "fee", Amount: crgtypes.AmountNegative},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := func(opType, value string) *types.Operation {
+		return &types.Operation{Type: opType, Amount: &types.Amount{Value: value, Currency: atom}}
+	}
+
+	tests := []struct {
+		name    string
+		ops     []*types.Operation
+		wantKey string
+		want    interface{}
+	}{
+		{"invalid operation", []*types.Operation{op("fee", "-1"), op("fee", "1")}, crgerrs.DetailsOperationIndex, 1},
+		{"nil operation", []*types.Operation{nil}, crgerrs.DetailsOperationIndex, 0},
+		{"unbalanced operations", []*types.Operation{op("transfer", "-10"), op("fee", "-1"), op("transfer", "9")}, crgerrs.DetailsOperationIndexes, []int{0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schemas.validate(tt.ops)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := crgerrs.ToRosetta(err).Details[tt.wantKey]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %s %v, got %v", tt.wantKey, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNilOperationSchemasAcceptEverything(t *testing.T) {
+	var schemas operationSchemas
+	if err := schemas.validate([]*types.Operation{{Type: "anything"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// errorInfo returns the info detail of the rosetta error
+func errorInfo(err error) string {
+	info, _ := crgerrs.ToRosetta(err).Details[crgerrs.DetailsInfo].(string)
+	return info
+}
@@ -0,0 +1,76 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package recording
+
+import (
+	"context"
+	"io"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// RecorderV2 is a types.ClientV2 decorator writing every call to a stream, as the Recorder does.
+// The recordings of both recorders have the same format and are served by the Replayer.
+type RecorderV2 struct {
+	*Recorder
+}
+
+var (
+	_ crgtypes.ClientV2                = (*RecorderV2)(nil)
+	_ crgtypes.PeerProvider            = (*RecorderV2)(nil)
+	_ crgtypes.MempoolTxsProvider      = (*RecorderV2)(nil)
+	_ crgtypes.TxHasher                = (*RecorderV2)(nil)
+	_ crgtypes.OperationSchemaProvider = (*RecorderV2)(nil)
+	_ crgtypes.CapabilityReporter      = (*RecorderV2)(nil)
+)
+
+// NewRecorderV2 returns a RecorderV2 forwarding the calls to client and writing them to w, one JSON entry per line.
+// The contexts are passed to client and are not recorded.
+func NewRecorderV2(client crgtypes.ClientV2, w io.Writer) *RecorderV2 {
+	return &RecorderV2{Recorder: newRecorder(client, w)}
+}
+
+func (r *RecorderV2) Bootstrap(ctx context.Context) error {
+	return r.bootstrap(ctx)
+}
+
+func (r *RecorderV2) Ready(ctx context.Context) error {
+	return r.ready(ctx)
+}
+
+func (r *RecorderV2) SupportedOperations(ctx context.Context) []string {
+	return r.supportedOperations(ctx)
+}
+
+func (r *RecorderV2) OperationStatuses(ctx context.Context) []*types.OperationStatus {
+	return r.operationStatuses(ctx)
+}
+
+func (r *RecorderV2) Version(ctx context.Context) string {
+	return r.version(ctx)
+}
+
+func (r *RecorderV2) TxOperationsAndSignersAccountIdentifiers(ctx context.Context, signed bool, txBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	return r.txOperationsAndSignersAccountIdentifiers(ctx, signed, txBytes)
+}
+
+func (r *RecorderV2) AccountIdentifierFromPublicKey(ctx context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	return r.accountIdentifierFromPublicKey(ctx, pubKey)
+}
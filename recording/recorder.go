@@ -0,0 +1,391 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// Recorder is a types.Client decorator writing every call to a stream, it is safe for concurrent use.
+// It implements the optional capabilities, supporting only the ones of the recorded client.
+type Recorder struct {
+	client       crgtypes.ClientV2
+	capabilities interface{} // value the capabilities of the recorded client are detected on
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+var (
+	_ crgtypes.Client                  = (*Recorder)(nil)
+	_ crgtypes.PeerProvider            = (*Recorder)(nil)
+	_ crgtypes.MempoolProvider         = (*Recorder)(nil)
+	_ crgtypes.UnconfirmedTxProvider   = (*Recorder)(nil)
+	_ crgtypes.MempoolTxsProvider      = (*Recorder)(nil)
+	_ crgtypes.TxBlockProvider         = (*Recorder)(nil)
+	_ crgtypes.TxInclusionWaiter       = (*Recorder)(nil)
+	_ crgtypes.TxSimulator             = (*Recorder)(nil)
+	_ crgtypes.MinGasPricesProvider    = (*Recorder)(nil)
+	_ crgtypes.TxHasher                = (*Recorder)(nil)
+	_ crgtypes.OperationSchemaProvider = (*Recorder)(nil)
+	_ crgtypes.CapabilityReporter      = (*Recorder)(nil)
+)
+
+// NewRecorder returns a Recorder forwarding the calls to client and writing them to w, one JSON entry per line.
+// The first entry records the capabilities supported by client.
+// Context aware clients are recorded with NewRecorderV2.
+func NewRecorder(client crgtypes.Client, w io.Writer) *Recorder {
+	return newRecorder(crgtypes.AdaptClient(client), w)
+}
+
+func newRecorder(client crgtypes.ClientV2, w io.Writer) *Recorder {
+	r := &Recorder{client: client, capabilities: crgtypes.UnwrapClient(client), enc: json.NewEncoder(w)}
+	r.record(methodCapabilities, nil, []interface{}{crgtypes.Capabilities(r.capabilities)}, nil)
+	return r
+}
+
+// SupportsCapability implements types.CapabilityReporter, the capabilities of the recorded client are supported
+func (r *Recorder) SupportsCapability(c crgtypes.Capability) bool {
+	return crgtypes.HasCapability(r.capabilities, c)
+}
+
+// Err returns the first error encountered while recording, recording failures
+// do not affect the results returned to the caller
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(method string, args []interface{}, results []interface{}, callErr error) {
+	entry := Entry{Method: method, Error: newError(callErr)}
+	var err error
+	if entry.Args, err = encode(args...); err == nil && callErr == nil {
+		entry.Results, err = encode(results...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err != nil {
+		r.err = fmt.Errorf("cannot encode %s call: %w", method, err)
+		return
+	}
+	if err := r.enc.Encode(entry); err != nil {
+		r.err = fmt.Errorf("cannot write %s call: %w", method, err)
+	}
+}
+
+func (r *Recorder) Bootstrap() error {
+	return r.bootstrap(context.Background())
+}
+
+func (r *Recorder) bootstrap(ctx context.Context) error {
+	err := r.client.Bootstrap(ctx)
+	r.record("Bootstrap", nil, nil, err)
+	return err
+}
+
+func (r *Recorder) Ready() error {
+	return r.ready(context.Background())
+}
+
+func (r *Recorder) ready(ctx context.Context) error {
+	err := r.client.Ready(ctx)
+	r.record("Ready", nil, nil, err)
+	return err
+}
+
+func (r *Recorder) Balances(ctx context.Context, addr string, height *int64) ([]*types.Amount, error) {
+	res, err := r.client.Balances(ctx, addr, height)
+	r.record("Balances", []interface{}{addr, height}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) BlockByHash(ctx context.Context, hash string) (crgtypes.BlockResponse, error) {
+	res, err := r.client.BlockByHash(ctx, hash)
+	r.record("BlockByHash", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) BlockByHeight(ctx context.Context, height *int64) (crgtypes.BlockResponse, error) {
+	res, err := r.client.BlockByHeight(ctx, height)
+	r.record("BlockByHeight", []interface{}{height}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) BlockTransactionsByHash(ctx context.Context, hash string) (crgtypes.BlockTransactionsResponse, error) {
+	res, err := r.client.BlockTransactionsByHash(ctx, hash)
+	r.record("BlockTransactionsByHash", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) BlockTransactionsByHeight(ctx context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	res, err := r.client.BlockTransactionsByHeight(ctx, height)
+	r.record("BlockTransactionsByHeight", []interface{}{height}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) GetTx(ctx context.Context, hash string) (*types.Transaction, error) {
+	res, err := r.client.GetTx(ctx, hash)
+	r.record("GetTx", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) GetUnconfirmedTx(ctx context.Context, hash string) (*types.Transaction, error) {
+	var (
+		res *types.Transaction
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityUnconfirmedTx).(crgtypes.UnconfirmedTxProvider); ok {
+		res, err = provider.GetUnconfirmedTx(ctx, hash)
+	} else {
+		err = notSupported(crgtypes.CapabilityUnconfirmedTx)
+	}
+	r.record("GetUnconfirmedTx", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) Mempool(ctx context.Context) ([]*types.TransactionIdentifier, error) {
+	var (
+		res []*types.TransactionIdentifier
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityMempool).(crgtypes.MempoolProvider); ok {
+		res, err = provider.Mempool(ctx)
+	} else {
+		err = notSupported(crgtypes.CapabilityMempool)
+	}
+	r.record("Mempool", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) Peers(ctx context.Context) ([]*types.Peer, error) {
+	var (
+		res []*types.Peer
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityPeers).(crgtypes.PeerProvider); ok {
+		res, err = provider.Peers(ctx)
+	} else {
+		err = notSupported(crgtypes.CapabilityPeers)
+	}
+	r.record("Peers", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) Status(ctx context.Context) (*types.SyncStatus, error) {
+	res, err := r.client.Status(ctx)
+	r.record("Status", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) PostTx(ctx context.Context, txBytes []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	res, meta, err := r.client.PostTx(ctx, txBytes)
+	r.record("PostTx", []interface{}{txBytes}, []interface{}{res, meta}, err)
+	return res, meta, err
+}
+
+func (r *Recorder) ConstructionMetadataFromOptions(ctx context.Context, options map[string]interface{}) (map[string]interface{}, error) {
+	res, err := r.client.ConstructionMetadataFromOptions(ctx, options)
+	r.record("ConstructionMetadataFromOptions", []interface{}{options}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) SupportedOperations() []string {
+	return r.supportedOperations(context.Background())
+}
+
+func (r *Recorder) supportedOperations(ctx context.Context) []string {
+	res := r.client.SupportedOperations(ctx)
+	r.record("SupportedOperations", nil, []interface{}{res}, nil)
+	return res
+}
+
+func (r *Recorder) OperationStatuses() []*types.OperationStatus {
+	return r.operationStatuses(context.Background())
+}
+
+func (r *Recorder) operationStatuses(ctx context.Context) []*types.OperationStatus {
+	res := r.client.OperationStatuses(ctx)
+	r.record("OperationStatuses", nil, []interface{}{res}, nil)
+	return res
+}
+
+func (r *Recorder) Version() string {
+	return r.version(context.Background())
+}
+
+func (r *Recorder) version(ctx context.Context) string {
+	res := r.client.Version(ctx)
+	r.record("Version", nil, []interface{}{res}, nil)
+	return res
+}
+
+func (r *Recorder) SignedTx(ctx context.Context, txBytes []byte, sigs []*types.Signature) ([]byte, error) {
+	res, err := r.client.SignedTx(ctx, txBytes, sigs)
+	r.record("SignedTx", []interface{}{txBytes, sigs}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) TxOperationsAndSignersAccountIdentifiers(signed bool, hexBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	return r.txOperationsAndSignersAccountIdentifiers(context.Background(), signed, hexBytes)
+}
+
+func (r *Recorder) txOperationsAndSignersAccountIdentifiers(ctx context.Context, signed bool, hexBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	ops, signers, err := r.client.TxOperationsAndSignersAccountIdentifiers(ctx, signed, hexBytes)
+	r.record("TxOperationsAndSignersAccountIdentifiers", []interface{}{signed, hexBytes}, []interface{}{ops, signers}, err)
+	return ops, signers, err
+}
+
+func (r *Recorder) ConstructionPayload(ctx context.Context, req *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, error) {
+	res, err := r.client.ConstructionPayload(ctx, req)
+	r.record("ConstructionPayload", []interface{}{req}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) PreprocessOperationsToOptions(ctx context.Context, req *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, error) {
+	res, err := r.client.PreprocessOperationsToOptions(ctx, req)
+	r.record("PreprocessOperationsToOptions", []interface{}{req}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	return r.accountIdentifierFromPublicKey(context.Background(), pubKey)
+}
+
+func (r *Recorder) accountIdentifierFromPublicKey(ctx context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	res, err := r.client.AccountIdentifierFromPublicKey(ctx, pubKey)
+	r.record("AccountIdentifierFromPublicKey", []interface{}{pubKey}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) MempoolTxs(ctx context.Context) ([]crgtypes.MempoolTx, error) {
+	var (
+		res []crgtypes.MempoolTx
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityMempoolTxs).(crgtypes.MempoolTxsProvider); ok {
+		res, err = provider.MempoolTxs(ctx)
+	} else {
+		err = notSupported(crgtypes.CapabilityMempoolTxs)
+	}
+	r.record("MempoolTxs", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) TxBlock(ctx context.Context, hash string) (*types.BlockIdentifier, error) {
+	var (
+		res *types.BlockIdentifier
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityTxBlock).(crgtypes.TxBlockProvider); ok {
+		res, err = provider.TxBlock(ctx, hash)
+	} else {
+		err = notSupported(crgtypes.CapabilityTxBlock)
+	}
+	r.record("TxBlock", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) WaitTxInclusion(ctx context.Context, hash string) (*crgtypes.TxInclusionResult, error) {
+	var (
+		res *crgtypes.TxInclusionResult
+		err error
+	)
+	if waiter, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityTxInclusion).(crgtypes.TxInclusionWaiter); ok {
+		res, err = waiter.WaitTxInclusion(ctx, hash)
+	} else {
+		err = notSupported(crgtypes.CapabilityTxInclusion)
+	}
+	r.record("WaitTxInclusion", []interface{}{hash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) SimulateTx(ctx context.Context, options map[string]interface{}, pubKeys []*types.PublicKey) (uint64, error) {
+	var (
+		res uint64
+		err error
+	)
+	if simulator, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityTxSimulation).(crgtypes.TxSimulator); ok {
+		res, err = simulator.SimulateTx(ctx, options, pubKeys)
+	} else {
+		err = notSupported(crgtypes.CapabilityTxSimulation)
+	}
+	r.record("SimulateTx", []interface{}{options, pubKeys}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) MinGasPrices(ctx context.Context) ([]*types.Amount, error) {
+	var (
+		res []*types.Amount
+		err error
+	)
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityMinGasPrices).(crgtypes.MinGasPricesProvider); ok {
+		res, err = provider.MinGasPrices(ctx)
+	} else {
+		err = notSupported(crgtypes.CapabilityMinGasPrices)
+	}
+	r.record("MinGasPrices", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *Recorder) HashTx(txBytes []byte) ([]byte, error) {
+	var (
+		res []byte
+		err error
+	)
+	if hasher, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityTxHash).(crgtypes.TxHasher); ok {
+		res, err = hasher.HashTx(txBytes)
+	} else {
+		err = notSupported(crgtypes.CapabilityTxHash)
+	}
+	r.record("HashTx", []interface{}{txBytes}, []interface{}{res}, err)
+	return res, err
+}
+
+// TxHashFormat returns the upper hex format if the client does not implement types.TxHasher
+func (r *Recorder) TxHashFormat() crgtypes.TxHashFormat {
+	res := crgtypes.TxHashFormatUpperHex
+	if hasher, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityTxHash).(crgtypes.TxHasher); ok {
+		res = hasher.TxHashFormat()
+	}
+	r.record("TxHashFormat", nil, []interface{}{res}, nil)
+	return res
+}
+
+// OperationSchemas returns no schemas if the client does not implement types.OperationSchemaProvider
+func (r *Recorder) OperationSchemas() []*crgtypes.OperationSchema {
+	var res []*crgtypes.OperationSchema
+	if provider, ok := crgtypes.WithCapability(r.capabilities, crgtypes.CapabilityOperationSchemas).(crgtypes.OperationSchemaProvider); ok {
+		res = provider.OperationSchemas()
+	}
+	r.record("OperationSchemas", nil, []interface{}{res}, nil)
+	return res
+}
@@ -0,0 +1,129 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+// Package recording records the traffic between the gateway and a client and replays it.
+// A Recorder decorates a client writing every call, with its arguments and its results or
+// error, to a JSON lines stream. A Replayer serves the recorded calls deterministically,
+// which turns a captured session into a regression test running without a node.
+//
+// The methods of types.Client and of the optional capabilities are recorded. The first entry
+// of a recording lists the capabilities of the recorded client, which are the only ones the
+// decorators report through types.CapabilityReporter, so that the gateway advertises and uses
+// the same capabilities as with the recorded client. Calls to capabilities the recorded client
+// does not support are recorded as ErrNotImplemented.
+//
+// Context aware clients are recorded with a RecorderV2, their recordings are replayed by the Replayer as well.
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// methodCapabilities is the method of the entry recording the capabilities of the recorded client
+const methodCapabilities = "Capabilities"
+
+// notSupported is returned by the decorators for the capabilities the recorded client does not support
+func notSupported(c crgtypes.Capability) error {
+	return crgerrs.WrapError(crgerrs.ErrNotImplemented, fmt.Sprintf("client does not support the %s capability", c))
+}
+
+// Entry is a recorded call
+type Entry struct {
+	// Method is the name of the called method, or Capabilities for the entry
+	// listing the capabilities of the recorded client
+	Method string `json:"method"`
+	// Args are the JSON encoded arguments, the context excluded
+	Args json.RawMessage `json:"args,omitempty"`
+	// Results are the JSON encoded results, the error excluded
+	Results json.RawMessage `json:"results,omitempty"`
+	// Error is the returned error, if any
+	Error *Error `json:"error,omitempty"`
+}
+
+// Error is a recorded error
+type Error struct {
+	// Rosetta is set if the error is an errors.Error
+	Rosetta *types.Error `json:"rosetta,omitempty"`
+	// Cause is the message of the cause of a rosetta error
+	Cause string `json:"cause,omitempty"`
+	// Context is set if the error is a context error, either canceled or deadline_exceeded
+	Context string `json:"context,omitempty"`
+	// Message is the message of the error
+	Message string `json:"message"`
+}
+
+const (
+	contextCanceled         = "canceled"
+	contextDeadlineExceeded = "deadline_exceeded"
+)
+
+// newError records err
+func newError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	recorded := &Error{Message: err.Error()}
+	var rosErr *crgerrs.Error
+	switch {
+	case stderrors.As(err, &rosErr):
+		recorded.Rosetta = crgerrs.ToRosetta(rosErr)
+		if cause := rosErr.Unwrap(); cause != nil {
+			recorded.Cause = cause.Error()
+		}
+	case stderrors.Is(err, context.Canceled):
+		recorded.Context = contextCanceled
+	case stderrors.Is(err, context.DeadlineExceeded):
+		recorded.Context = contextDeadlineExceeded
+	}
+	return recorded
+}
+
+// err rebuilds the recorded error, rosetta errors are rebuilt from the registry
+func (e *Error) err(registry *crgerrs.Registry) error {
+	switch {
+	case e == nil:
+		return nil
+	case e.Rosetta != nil:
+		err := registry.FromRosetta(e.Rosetta)
+		if e.Cause != "" {
+			return crgerrs.Wrap(err, stderrors.New(e.Cause))
+		}
+		return err
+	case e.Context == contextCanceled:
+		return context.Canceled
+	case e.Context == contextDeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return stderrors.New(e.Message)
+	}
+}
+
+// encode encodes values as a JSON array
+func encode(values ...interface{}) (json.RawMessage, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(values)
+}
@@ -0,0 +1,321 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package recording_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/recording"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// request is a rosetta request of the recorded session
+type request struct {
+	path string
+	body interface{}
+}
+
+// response is the response of the gateway to a request
+type response struct {
+	status int
+	body   string
+}
+
+// serve sends the requests to a gateway serving client and returns the responses
+func serve(t *testing.T, network *types.NetworkIdentifier, client crgtypes.Client, requests []request) []response {
+	t.Helper()
+	return serveSettings(t, server.Settings{Network: network, Client: client}, requests)
+}
+
+// serveSettings sends the requests to a gateway built with settings and returns the responses
+func serveSettings(t *testing.T, settings server.Settings, requests []request) []response {
+	t.Helper()
+	settings.Retries = 1
+	settings.RetryWait = time.Millisecond
+	srv, err := server.NewServer(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := make([]response, len(requests))
+	for i, req := range requests {
+		body, err := json.Marshal(req.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, req.path, bytes.NewReader(body)))
+		responses[i] = response{status: rec.Code, body: rec.Body.String()}
+	}
+	return responses
+}
+
+// recordedMethods returns the methods of the recorded entries, in order
+func recordedMethods(t *testing.T, recorded []byte) []string {
+	t.Helper()
+	var methods []string
+	scanner := bufio.NewScanner(bytes.NewReader(recorded))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		entry := new(recording.Entry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			t.Fatal(err)
+		}
+		methods = append(methods, entry.Method)
+	}
+	return methods
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	alice, bob := clienttest.NewAccount("alice"), clienttest.NewAccount("bob")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	client.CommitBlock()
+	included, err := client.Transfer(alice, bob.Address, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := client.CommitBlock()
+	pending, err := client.Transfer(alice, bob.Address, big.NewInt(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := client.Network()
+	requests := []request{
+		{"/network/options", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/network/status", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/block", &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{Index: &block.Index}}},
+		{"/block/transaction", &types.BlockTransactionRequest{NetworkIdentifier: network, BlockIdentifier: block, TransactionIdentifier: included}},
+		{"/block/transaction", &types.BlockTransactionRequest{NetworkIdentifier: network, BlockIdentifier: block, TransactionIdentifier: pending}},
+		{"/mempool", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/mempool/transaction", &types.MempoolTransactionRequest{NetworkIdentifier: network, TransactionIdentifier: pending}},
+		{"/account/balance", &types.AccountBalanceRequest{NetworkIdentifier: network, AccountIdentifier: alice.Identifier()}},
+		{"/account/balance", &types.AccountBalanceRequest{NetworkIdentifier: network, AccountIdentifier: bob.Identifier()}},
+	}
+
+	var buf bytes.Buffer
+	recorder := recording.NewRecorder(client, &buf)
+	recorded := serve(t, network, recorder, requests)
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recording failed: %s", err)
+	}
+	for i, res := range recorded {
+		if res.status != http.StatusOK && requests[i].path != "/block/transaction" {
+			t.Fatalf("%s failed with status %d: %s", requests[i].path, res.status, res.body)
+		}
+	}
+
+	methods := recordedMethods(t, buf.Bytes())
+	if methods[0] != "Capabilities" {
+		t.Fatalf("expected the recording to start with the capabilities, got %s", methods[0])
+	}
+	for _, method := range []string{"TxBlock", "MempoolTxs", "OperationSchemas"} {
+		found := false
+		for _, m := range methods {
+			found = found || m == method
+		}
+		if !found {
+			t.Errorf("expected %s to be recorded, got %v", method, methods)
+		}
+	}
+
+	replayer, err := recording.NewReplayer(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := serve(t, network, replayer, requests)
+	for i := range requests {
+		if recorded[i] != replayed[i] {
+			t.Errorf("%s: replayed response differs from the recorded one\nrecorded: %d %s\nreplayed: %d %s",
+				requests[i].path, recorded[i].status, recorded[i].body, replayed[i].status, replayed[i].body)
+		}
+	}
+}
+
+func TestRecorderV2(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	alice := clienttest.NewAccount("alice")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	block := client.CommitBlock()
+
+	network := client.Network()
+	requests := []request{
+		{"/network/options", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/block", &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{Index: &block.Index}}},
+		{"/account/balance", &types.AccountBalanceRequest{NetworkIdentifier: network, AccountIdentifier: alice.Identifier()}},
+		{"/construction/derive", &types.ConstructionDeriveRequest{NetworkIdentifier: network, PublicKey: alice.PublicKey()}},
+	}
+	direct := serve(t, network, client, requests)
+
+	var buf bytes.Buffer
+	recorder := recording.NewRecorderV2(crgtypes.AdaptClient(client), &buf)
+	recorded := serveSettings(t, server.Settings{Network: network, ClientV2: recorder}, requests)
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recording failed: %s", err)
+	}
+	// the recordings of both recorders are served by the replayer
+	replayer, err := recording.NewReplayer(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := serve(t, network, replayer, requests)
+
+	for i, req := range requests {
+		if recorded[i] != direct[i] || replayed[i] != direct[i] {
+			t.Errorf("%s: responses differ\nclient:   %d %s\nrecorder: %d %s\nreplayer: %d %s", req.path,
+				direct[i].status, direct[i].body, recorded[i].status, recorded[i].body, replayed[i].status, replayed[i].body)
+		}
+	}
+}
+
+func TestRecorderCapabilities(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	// hide the optional capabilities of the client
+	plain := struct{ crgtypes.Client }{client}
+
+	tests := []struct {
+		name   string
+		client crgtypes.Client
+		want   map[crgtypes.Capability]bool
+	}{
+		{
+			name:   "clienttest",
+			client: client,
+			want: map[crgtypes.Capability]bool{
+				crgtypes.CapabilityPeers:            true,
+				crgtypes.CapabilityMempool:          true,
+				crgtypes.CapabilityUnconfirmedTx:    true,
+				crgtypes.CapabilityTxBlock:          true,
+				crgtypes.CapabilityTxInclusion:      true,
+				crgtypes.CapabilityMempoolTxs:       true,
+				crgtypes.CapabilityOperationSchemas: true,
+				crgtypes.CapabilityTxHash:           false,
+				crgtypes.CapabilityTxSimulation:     false,
+			},
+		},
+		{
+			name:   "no optional capabilities",
+			client: plain,
+			want: map[crgtypes.Capability]bool{
+				crgtypes.CapabilityPeers:            false,
+				crgtypes.CapabilityMempool:          false,
+				crgtypes.CapabilityUnconfirmedTx:    false,
+				crgtypes.CapabilityTxBlock:          false,
+				crgtypes.CapabilityTxInclusion:      false,
+				crgtypes.CapabilityMempoolTxs:       false,
+				crgtypes.CapabilityOperationSchemas: false,
+				crgtypes.CapabilityTxHash:           false,
+				crgtypes.CapabilityTxSimulation:     false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			recorder := recording.NewRecorder(tt.client, &buf)
+			replayer, err := recording.NewReplayer(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for c, want := range tt.want {
+				if got := crgtypes.HasCapability(recorder, c); got != want {
+					t.Errorf("recorder: expected capability %s %t, got %t", c, want, got)
+				}
+				if got := crgtypes.HasCapability(replayer, c); got != want {
+					t.Errorf("replayer: expected capability %s %t, got %t", c, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkOptionsCapabilities(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	network := client.Network()
+	requests := []request{
+		{"/network/options", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/network/status", &types.NetworkRequest{NetworkIdentifier: network}},
+		{"/mempool", &types.NetworkRequest{NetworkIdentifier: network}},
+	}
+	client.SetPeers(&types.Peer{PeerID: "peer"})
+	client.CommitBlock()
+
+	for name, c := range map[string]crgtypes.Client{
+		"clienttest":               client,
+		"no optional capabilities": struct{ crgtypes.Client }{client},
+	} {
+		t.Run(name, func(t *testing.T) {
+			direct := serve(t, network, c, requests)
+
+			var buf bytes.Buffer
+			recorded := serve(t, network, recording.NewRecorder(c, &buf), requests)
+			replayer, err := recording.NewReplayer(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed := serve(t, network, replayer, requests)
+
+			for i, req := range requests {
+				if recorded[i] != direct[i] {
+					t.Errorf("%s: recorder response differs from the client one\nclient:   %d %s\nrecorder: %d %s",
+						req.path, direct[i].status, direct[i].body, recorded[i].status, recorded[i].body)
+				}
+				if replayed[i] != direct[i] {
+					t.Errorf("%s: replayer response differs from the client one\nclient:   %d %s\nreplayer: %d %s",
+						req.path, direct[i].status, direct[i].body, replayed[i].status, replayed[i].body)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorderUnsupportedCapability(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	var buf bytes.Buffer
+	recorder := recording.NewRecorder(client, &buf)
+
+	_, err := recorder.HashTx([]byte("tx"))
+	if !errors.Is(err, crgerrs.ErrNotImplemented) {
+		t.Fatalf("expected ErrNotImplemented, got %v", err)
+	}
+
+	replayer, err := recording.NewReplayer(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = replayer.HashTx([]byte("tx"))
+	if !errors.Is(err, crgerrs.ErrNotImplemented) {
+		t.Fatalf("expected the recorded ErrNotImplemented, got %v", err)
+	}
+	if _, err := replayer.HashTx([]byte("other")); !errors.Is(err, recording.ErrNoRecording) {
+		t.Fatalf("expected ErrNoRecording, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// ErrNoRecording is returned by the Replayer for calls which were not recorded
+var ErrNoRecording = errors.New("no recording for call")
+
+// Replayer is a types.Client serving recorded calls, calls are matched by method and
+// arguments. Identical calls are served in recorded order, once exhausted the last
+// recording is served again. It supports the recorded client capabilities.
+// It is safe for concurrent use.
+type Replayer struct {
+	registry     *crgerrs.Registry
+	capabilities map[crgtypes.Capability]bool
+
+	mu      sync.Mutex
+	entries map[string][]*Entry
+	served  map[string]int
+}
+
+var (
+	_ crgtypes.Client                  = (*Replayer)(nil)
+	_ crgtypes.PeerProvider            = (*Replayer)(nil)
+	_ crgtypes.MempoolProvider         = (*Replayer)(nil)
+	_ crgtypes.UnconfirmedTxProvider   = (*Replayer)(nil)
+	_ crgtypes.MempoolTxsProvider      = (*Replayer)(nil)
+	_ crgtypes.TxBlockProvider         = (*Replayer)(nil)
+	_ crgtypes.TxInclusionWaiter       = (*Replayer)(nil)
+	_ crgtypes.TxSimulator             = (*Replayer)(nil)
+	_ crgtypes.MinGasPricesProvider    = (*Replayer)(nil)
+	_ crgtypes.TxHasher                = (*Replayer)(nil)
+	_ crgtypes.OperationSchemaProvider = (*Replayer)(nil)
+	_ crgtypes.CapabilityReporter      = (*Replayer)(nil)
+)
+
+// NewReplayer reads the recording written by a Recorder, recorded rosetta
+// errors are rebuilt from the default error registry
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	p := &Replayer{
+		registry:     crgerrs.DefaultRegistry(),
+		capabilities: make(map[crgtypes.Capability]bool),
+		entries:      make(map[string][]*Entry),
+		served:       make(map[string]int),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		entry := new(Entry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d: %w", line, err)
+		}
+		key, err := callKey(entry.Method, entry.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d: %w", line, err)
+		}
+		p.entries[key] = append(p.entries[key], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read recording: %w", err)
+	}
+	if err := p.loadCapabilities(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// loadCapabilities loads the capabilities of the recorded client
+func (p *Replayer) loadCapabilities() error {
+	if len(p.entries[methodCapabilities]) == 0 {
+		return nil
+	}
+	var capabilities []crgtypes.Capability
+	if err := p.replay(methodCapabilities, nil, &capabilities); err != nil {
+		return fmt.Errorf("invalid recorded capabilities: %w", err)
+	}
+	for _, c := range capabilities {
+		p.capabilities[c] = true
+	}
+	return nil
+}
+
+// SupportsCapability implements types.CapabilityReporter, the recorded capabilities are supported
+func (p *Replayer) SupportsCapability(c crgtypes.Capability) bool {
+	return p.capabilities[c]
+}
+
+// LoadReplayer reads the recording stored in the file at path
+func LoadReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayer(f)
+}
+
+// callKey identifies a call given its method and its encoded arguments,
+// arguments are re-encoded so that the key does not depend on formatting
+func callKey(method string, args json.RawMessage) (string, error) {
+	if len(args) == 0 {
+		return method, nil
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	if v == nil {
+		return method, nil
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return method + string(canonical), nil
+}
+
+// replay decodes the recorded results of the call into results and returns the recorded error,
+// numbers in untyped values are decoded as json.Number to preserve their encoding
+func (p *Replayer) replay(method string, args []interface{}, results ...interface{}) error {
+	encoded, err := encode(args...)
+	if err != nil {
+		return fmt.Errorf("cannot encode %s arguments: %w", method, err)
+	}
+	key, err := callKey(method, encoded)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	entries := p.entries[key]
+	if len(entries) == 0 {
+		p.mu.Unlock()
+		return fmt.Errorf("%w: %s %s", ErrNoRecording, method, encoded)
+	}
+	i := p.served[key]
+	if i < len(entries)-1 {
+		p.served[key]++
+	}
+	entry := entries[i]
+	p.mu.Unlock()
+
+	if entry.Error != nil {
+		return entry.Error.err(p.registry)
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	raw := make([]json.RawMessage, 0, len(results))
+	if err := json.Unmarshal(entry.Results, &raw); err != nil || len(raw) != len(results) {
+		return fmt.Errorf("invalid recorded results for %s", method)
+	}
+	for i, result := range results {
+		dec := json.NewDecoder(bytes.NewReader(raw[i]))
+		dec.UseNumber()
+		if err := dec.Decode(result); err != nil {
+			return fmt.Errorf("invalid recorded results for %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// replayOrPanic replays calls whose method cannot return errors
+func (p *Replayer) replayOrPanic(method string, results ...interface{}) {
+	if err := p.replay(method, nil, results...); err != nil {
+		panic(err)
+	}
+}
+
+func (p *Replayer) Bootstrap() error {
+	return p.replay("Bootstrap", nil)
+}
+
+func (p *Replayer) Ready() error {
+	return p.replay("Ready", nil)
+}
+
+func (p *Replayer) Balances(_ context.Context, addr string, height *int64) ([]*types.Amount, error) {
+	var res []*types.Amount
+	err := p.replay("Balances", []interface{}{addr, height}, &res)
+	return res, err
+}
+
+func (p *Replayer) BlockByHash(_ context.Context, hash string) (crgtypes.BlockResponse, error) {
+	var res crgtypes.BlockResponse
+	err := p.replay("BlockByHash", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) BlockByHeight(_ context.Context, height *int64) (crgtypes.BlockResponse, error) {
+	var res crgtypes.BlockResponse
+	err := p.replay("BlockByHeight", []interface{}{height}, &res)
+	return res, err
+}
+
+func (p *Replayer) BlockTransactionsByHash(_ context.Context, hash string) (crgtypes.BlockTransactionsResponse, error) {
+	var res crgtypes.BlockTransactionsResponse
+	err := p.replay("BlockTransactionsByHash", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) BlockTransactionsByHeight(_ context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	var res crgtypes.BlockTransactionsResponse
+	err := p.replay("BlockTransactionsByHeight", []interface{}{height}, &res)
+	return res, err
+}
+
+func (p *Replayer) GetTx(_ context.Context, hash string) (*types.Transaction, error) {
+	var res *types.Transaction
+	err := p.replay("GetTx", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) GetUnconfirmedTx(_ context.Context, hash string) (*types.Transaction, error) {
+	var res *types.Transaction
+	err := p.replay("GetUnconfirmedTx", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) Mempool(_ context.Context) ([]*types.TransactionIdentifier, error) {
+	var res []*types.TransactionIdentifier
+	err := p.replay("Mempool", nil, &res)
+	return res, err
+}
+
+func (p *Replayer) Peers(_ context.Context) ([]*types.Peer, error) {
+	var res []*types.Peer
+	err := p.replay("Peers", nil, &res)
+	return res, err
+}
+
+func (p *Replayer) Status(_ context.Context) (*types.SyncStatus, error) {
+	var res *types.SyncStatus
+	err := p.replay("Status", nil, &res)
+	return res, err
+}
+
+func (p *Replayer) PostTx(_ context.Context, txBytes []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	var (
+		res  *types.TransactionIdentifier
+		meta map[string]interface{}
+	)
+	err := p.replay("PostTx", []interface{}{txBytes}, &res, &meta)
+	return res, meta, err
+}
+
+func (p *Replayer) ConstructionMetadataFromOptions(_ context.Context, options map[string]interface{}) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := p.replay("ConstructionMetadataFromOptions", []interface{}{options}, &res)
+	return res, err
+}
+
+// SupportedOperations panics if the call was not recorded, as the method cannot return errors
+func (p *Replayer) SupportedOperations() []string {
+	var res []string
+	p.replayOrPanic("SupportedOperations", &res)
+	return res
+}
+
+// OperationStatuses panics if the call was not recorded, as the method cannot return errors
+func (p *Replayer) OperationStatuses() []*types.OperationStatus {
+	var res []*types.OperationStatus
+	p.replayOrPanic("OperationStatuses", &res)
+	return res
+}
+
+// Version panics if the call was not recorded, as the method cannot return errors
+func (p *Replayer) Version() string {
+	var res string
+	p.replayOrPanic("Version", &res)
+	return res
+}
+
+func (p *Replayer) SignedTx(_ context.Context, txBytes []byte, sigs []*types.Signature) ([]byte, error) {
+	var res []byte
+	err := p.replay("SignedTx", []interface{}{txBytes, sigs}, &res)
+	return res, err
+}
+
+func (p *Replayer) TxOperationsAndSignersAccountIdentifiers(signed bool, hexBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	var (
+		ops     []*types.Operation
+		signers []*types.AccountIdentifier
+	)
+	err := p.replay("TxOperationsAndSignersAccountIdentifiers", []interface{}{signed, hexBytes}, &ops, &signers)
+	return ops, signers, err
+}
+
+func (p *Replayer) ConstructionPayload(_ context.Context, req *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, error) {
+	var res *types.ConstructionPayloadsResponse
+	err := p.replay("ConstructionPayload", []interface{}{req}, &res)
+	return res, err
+}
+
+func (p *Replayer) PreprocessOperationsToOptions(_ context.Context, req *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, error) {
+	var res *types.ConstructionPreprocessResponse
+	err := p.replay("PreprocessOperationsToOptions", []interface{}{req}, &res)
+	return res, err
+}
+
+func (p *Replayer) AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	var res *types.AccountIdentifier
+	err := p.replay("AccountIdentifierFromPublicKey", []interface{}{pubKey}, &res)
+	return res, err
+}
+
+func (p *Replayer) MempoolTxs(_ context.Context) ([]crgtypes.MempoolTx, error) {
+	var res []crgtypes.MempoolTx
+	err := p.replay("MempoolTxs", nil, &res)
+	return res, err
+}
+
+func (p *Replayer) TxBlock(_ context.Context, hash string) (*types.BlockIdentifier, error) {
+	var res *types.BlockIdentifier
+	err := p.replay("TxBlock", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) WaitTxInclusion(_ context.Context, hash string) (*crgtypes.TxInclusionResult, error) {
+	var res *crgtypes.TxInclusionResult
+	err := p.replay("WaitTxInclusion", []interface{}{hash}, &res)
+	return res, err
+}
+
+func (p *Replayer) SimulateTx(_ context.Context, options map[string]interface{}, pubKeys []*types.PublicKey) (uint64, error) {
+	var res uint64
+	err := p.replay("SimulateTx", []interface{}{options, pubKeys}, &res)
+	return res, err
+}
+
+func (p *Replayer) MinGasPrices(_ context.Context) ([]*types.Amount, error) {
+	var res []*types.Amount
+	err := p.replay("MinGasPrices", nil, &res)
+	return res, err
+}
+
+func (p *Replayer) HashTx(txBytes []byte) ([]byte, error) {
+	var res []byte
+	err := p.replay("HashTx", []interface{}{txBytes}, &res)
+	return res, err
+}
+
+// TxHashFormat panics if the call was not recorded, as the method cannot return errors
+func (p *Replayer) TxHashFormat() crgtypes.TxHashFormat {
+	var res crgtypes.TxHashFormat
+	p.replayOrPanic("TxHashFormat", &res)
+	return res
+}
+
+// OperationSchemas panics if the call was not recorded, as the method cannot return errors
+func (p *Replayer) OperationSchemas() []*crgtypes.OperationSchema {
+	var res []*crgtypes.OperationSchema
+	p.replayOrPanic("OperationSchemas", &res)
+	return res
+}
@@ -0,0 +1,176 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	assert "github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// adapterSwitch serves the rosetta API through either the online or the offline
+// adapter, the adapters are built lazily and the mode can be switched at runtime
+type adapterSwitch struct {
+	asserter *assert.Asserter
+
+	mu       sync.Mutex
+	adapters map[bool]*modeAdapter // indexed by offline
+	current  atomic.Value          // *modeAdapter
+}
+
+// modeAdapter is an adapter with the router serving it
+type modeAdapter struct {
+	offline bool
+	api     crgtypes.API
+	handler http.Handler
+}
+
+// newAdapterSwitch builds the adapter of the mode defined in the settings
+func newAdapterSwitch(ctx context.Context, settings Settings, asserter *assert.Asserter) (*adapterSwitch, error) {
+	s := &adapterSwitch{
+		asserter: asserter,
+		adapters: make(map[bool]*modeAdapter, 2),
+	}
+	if err := s.setOffline(ctx, settings, settings.Offline); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *adapterSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.current.Load().(*modeAdapter).handler.ServeHTTP(w, r)
+}
+
+// offline reports if the offline adapter is serving the requests
+func (s *adapterSwitch) offline() bool {
+	return s.current.Load().(*modeAdapter).offline
+}
+
+// setOffline switches to the offline or online adapter, building it with the given settings if needed.
+// Building the online adapter waits for the node to be ready, so it is done without holding the lock,
+// if two callers build the same adapter concurrently the first one registered is kept.
+// The context bounds the build of the adapter.
+func (s *adapterSwitch) setOffline(ctx context.Context, settings Settings, offline bool) error {
+	s.mu.Lock()
+	adapter, ok := s.adapters[offline]
+	s.mu.Unlock()
+	if !ok {
+		built, err := s.newModeAdapter(ctx, settings, offline)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if adapter, ok = s.adapters[offline]; !ok {
+			adapter = built
+			s.adapters[offline] = adapter
+		}
+	}
+	s.current.Store(adapter)
+	return nil
+}
+
+// newModeAdapter builds the offline or online adapter and its router
+func (s *adapterSwitch) newModeAdapter(ctx context.Context, settings Settings, offline bool) (*modeAdapter, error) {
+	var (
+		api crgtypes.API
+		err error
+	)
+	switch offline {
+	case true:
+		api, err = newOfflineAdapter(ctx, settings)
+	case false:
+		api, err = newOnlineAdapter(ctx, settings)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &modeAdapter{
+		offline: offline,
+		api:     api,
+		handler: server.NewRouter(
+			server.NewAccountAPIController(api, s.asserter),
+			server.NewBlockAPIController(api, s.asserter),
+			server.NewNetworkAPIController(api, s.asserter),
+			server.NewMempoolAPIController(api, s.asserter),
+			server.NewConstructionAPIController(api, s.asserter),
+		),
+	}, nil
+}
+
+// timeoutCounter is implemented by the adapters counting the timed out requests
+type timeoutCounter interface {
+	TimeoutCounts() map[string]uint64
+}
+
+// timeoutCounts returns the timed out requests per endpoint of the built adapters
+func (s *adapterSwitch) timeoutCounts() map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]uint64)
+	for _, adapter := range s.adapters {
+		counter, ok := adapter.api.(timeoutCounter)
+		if !ok {
+			continue
+		}
+		for endpoint, n := range counter.TimeoutCounts() {
+			counts[endpoint] += n
+		}
+	}
+	return counts
+}
+
+// cacheFlusher is implemented by the adapters caching data
+type cacheFlusher interface {
+	FlushCaches()
+}
+
+// flushCaches flushes the caches of the built adapters
+func (s *adapterSwitch) flushCaches() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, adapter := range s.adapters {
+		if flusher, ok := adapter.api.(cacheFlusher); ok {
+			flusher.FlushCaches()
+		}
+	}
+}
+
+// updateOptions updates the options of the built adapters
+func (s *adapterSwitch) updateOptions(opts service.Options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, adapter := range s.adapters {
+		updater, ok := adapter.api.(optionsUpdater)
+		if !ok {
+			continue
+		}
+		if err := updater.UpdateOptions(opts); err != nil {
+			return fmt.Errorf("cannot update adapter options: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+const (
+	modeOnline  = "online"
+	modeOffline = "offline"
+)
+
+// redacted replaces secret values in the config dumped by the admin API
+const redacted = "[REDACTED]"
+
+// secretKey matches the client config keys whose values are redacted
+var secretKey = regexp.MustCompile(`(?i)(secret|password|passphrase|token|mnemonic|credential|private|key)`)
+
+// adminError is the body of the admin API error responses
+type adminError struct {
+	Error string `json:"error"`
+}
+
+type maintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+type modeStatus struct {
+	Mode string `json:"mode"`
+}
+
+type drainRequest struct {
+	Address string `json:"address"`
+	Drained bool   `json:"drained"`
+}
+
+// effectiveConfig is the view of the running settings returned by the admin API
+type effectiveConfig struct {
+	Network              *types.NetworkIdentifier `json:"network"`
+	ClientName           string                   `json:"client_name,omitempty"`
+	ClientSDKVersion     string                   `json:"client_sdk_version,omitempty"`
+	ClientConfig         map[string]interface{}   `json:"client_config,omitempty"`
+	Listen               string                   `json:"listen"`
+	Offline              bool                     `json:"offline"`
+	Mode                 string                   `json:"mode"`
+	Maintenance          bool                     `json:"maintenance"`
+	Retries              int                      `json:"retries"`
+	RetryWait            string                   `json:"retry_wait"`
+	GasPrices            []*types.Amount          `json:"gas_prices,omitempty"`
+	GasAdjustment        float64                  `json:"gas_adjustment"`
+	VerifyRoundTrip      bool                     `json:"verify_round_trip"`
+	Debug                bool                     `json:"debug"`
+	HTTPStatusFromErrors bool                     `json:"http_status_from_errors"`
+	TLSCertFile          string                   `json:"tls_cert_file,omitempty"`
+	TLSKeyFile           string                   `json:"tls_key_file,omitempty"`
+	ReadTimeout          string                   `json:"read_timeout"`
+	WriteTimeout         string                   `json:"write_timeout"`
+	IdleTimeout          string                   `json:"idle_timeout"`
+	MaxHeaderBytes       int                      `json:"max_header_bytes"`
+	MaxRequestBodyBytes  int64                    `json:"max_request_body_bytes"`
+	AdminListen          string                   `json:"admin_listen"`
+	AdminToken           string                   `json:"admin_token"`
+	EndpointTimeout      string                   `json:"endpoint_timeout"`
+	EndpointTimeouts     map[string]string        `json:"endpoint_timeouts,omitempty"`
+	InlineTransactions   int                      `json:"inline_transactions_limit"`
+	RateLimit            float64                  `json:"rate_limit"`
+	RateLimitBurst       int                      `json:"rate_limit_burst"`
+	LogLevel             string                   `json:"log_level"`
+}
+
+// SetMaintenance enables or disables the maintenance mode, while enabled
+// every rosetta endpoint returns the retriable errors.ErrMaintenance
+func (h Server) SetMaintenance(enabled bool) {
+	h.runtime.setMaintenance(enabled)
+}
+
+// SetOffline switches the server between the online and the offline adapter, see SetOfflineContext
+func (h Server) SetOffline(offline bool) error {
+	return h.SetOfflineContext(context.Background(), offline)
+}
+
+// SetOfflineContext switches the server between the online and the offline adapter, the adapter
+// is built with the current settings the first time it is used. Building the online adapter waits
+// for the node to be ready, canceling the context stops waiting.
+func (h Server) SetOfflineContext(ctx context.Context, offline bool) error {
+	if err := h.adapters.setOffline(ctx, h.runtime.load(), offline); err != nil {
+		return err
+	}
+	// the settings can be reloaded while the adapter is built, apply the current ones
+	h.runtime.mu.Lock()
+	defer h.runtime.mu.Unlock()
+	return h.adapters.updateOptions(h.runtime.load().serviceOptions())
+}
+
+// AdminHandler returns the handler serving the admin API, to embed it in another HTTP server,
+// every request must carry the admin token as bearer token
+func (h Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/maintenance", h.handleMaintenance)
+	mux.HandleFunc("/mode", h.handleMode)
+	mux.HandleFunc("/caches/flush", h.handleFlushCaches)
+	mux.HandleFunc("/nodes", h.handleNodes)
+	mux.HandleFunc("/nodes/drain", h.handleDrainNode)
+	mux.HandleFunc("/config", h.handleConfig)
+	mux.HandleFunc("/timeouts", h.handleTimeouts)
+	return adminAuthMiddleware(h.settings.AdminToken, mux)
+}
+
+// adminAuthMiddleware rejects the requests which do not carry token as bearer token
+func adminAuthMiddleware(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if !strings.HasPrefix(auth, prefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAdminError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req maintenanceStatus
+		if !decodeAdminRequest(w, r, &req) {
+			return
+		}
+		h.SetMaintenance(req.Enabled)
+	default:
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeAdminJSON(w, maintenanceStatus{Enabled: h.runtime.inMaintenance()})
+}
+
+func (h Server) handleMode(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req modeStatus
+		if !decodeAdminRequest(w, r, &req) {
+			return
+		}
+		if req.Mode != modeOnline && req.Mode != modeOffline {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid mode %q, expected %s or %s", req.Mode, modeOnline, modeOffline))
+			return
+		}
+		if err := h.SetOfflineContext(r.Context(), req.Mode == modeOffline); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, err)
+			return
+		}
+	default:
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeAdminJSON(w, modeStatus{Mode: h.mode()})
+}
+
+func (h Server) handleFlushCaches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	// the gateway caches are flushed even if the client has none
+	h.adapters.flushCaches()
+	if flusher, ok := h.runtime.load().capabilities().(crgtypes.CacheFlusher); ok {
+		if err := flusher.FlushCaches(); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	manager, ok := h.runtime.load().capabilities().(crgtypes.UpstreamNodesManager)
+	if !ok {
+		writeAdminError(w, http.StatusNotImplemented, fmt.Errorf("the client does not support managing upstream nodes"))
+		return
+	}
+	writeAdminJSON(w, manager.UpstreamNodes())
+}
+
+func (h Server) handleDrainNode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	manager, ok := h.runtime.load().capabilities().(crgtypes.UpstreamNodesManager)
+	if !ok {
+		writeAdminError(w, http.StatusNotImplemented, fmt.Errorf("the client does not support managing upstream nodes"))
+		return
+	}
+	var req drainRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if req.Address == "" {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("address is required"))
+		return
+	}
+	if err := manager.SetNodeDrained(req.Address, req.Drained); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeAdminJSON(w, manager.UpstreamNodes())
+}
+
+func (h Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	s := h.runtime.load()
+	writeAdminJSON(w, effectiveConfig{
+		Network:              s.Network,
+		ClientName:           s.ClientName,
+		ClientSDKVersion:     s.ClientSDKVersion,
+		ClientConfig:         redactMap(s.ClientConfig),
+		Listen:               s.Listen,
+		Offline:              s.Offline,
+		Mode:                 h.mode(),
+		Maintenance:          h.runtime.inMaintenance(),
+		Retries:              s.Retries,
+		RetryWait:            s.RetryWait.String(),
+		GasPrices:            s.GasPrices,
+		GasAdjustment:        s.GasAdjustment,
+		VerifyRoundTrip:      s.VerifyRoundTrip,
+		Debug:                s.Debug,
+		HTTPStatusFromErrors: s.HTTPStatusFromErrors,
+		TLSCertFile:          s.TLSCertFile,
+		TLSKeyFile:           s.TLSKeyFile,
+		ReadTimeout:          s.ReadTimeout.String(),
+		WriteTimeout:         s.WriteTimeout.String(),
+		IdleTimeout:          s.IdleTimeout.String(),
+		MaxHeaderBytes:       s.MaxHeaderBytes,
+		MaxRequestBodyBytes:  s.MaxRequestBodyBytes,
+		AdminListen:          s.AdminListen,
+		AdminToken:           redacted,
+		EndpointTimeout:      s.EndpointTimeout.String(),
+		EndpointTimeouts:     durationStrings(s.EndpointTimeouts),
+		InlineTransactions:   s.InlineTransactionsLimit,
+		RateLimit:            s.RateLimit,
+		RateLimitBurst:       s.rateLimitBurst(),
+		LogLevel:             logging.GetLevel().String(),
+	})
+}
+
+func (h Server) handleTimeouts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeAdminJSON(w, h.TimeoutCounts())
+}
+
+// TimeoutCounts returns the number of requests which exceeded their endpoint timeout,
+// per endpoint path, since the server started
+func (h Server) TimeoutCounts() map[string]uint64 {
+	return h.adapters.timeoutCounts()
+}
+
+// durationStrings formats the durations of m
+func durationStrings(m map[string]time.Duration) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, d := range m {
+		out[k] = d.String()
+	}
+	return out
+}
+
+// mode returns the name of the adapter serving the requests
+func (h Server) mode() string {
+	if h.adapters.offline() {
+		return modeOffline
+	}
+	return modeOnline
+}
+
+// redactMap returns a copy of m with the values of secret keys redacted, recursively
+func redactMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if secretKey.MatchString(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return redactMap(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = redactValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// decodeAdminRequest decodes the request body into v, writing the error response on failure
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(adminError{Error: err.Error()})
+}
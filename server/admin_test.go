@@ -0,0 +1,282 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+const adminToken = "admin-secret"
+
+func TestAdminAPI(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	srv, err := server.NewServer(server.Settings{
+		Network:      client.Network(),
+		Client:       client,
+		Retries:      1,
+		AdminListen:  "localhost:0",
+		AdminToken:   adminToken,
+		ClientConfig: map[string]interface{}{"endpoint": "localhost:9090", "api_key": "key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := srv.AdminHandler()
+	networkStatus := func() *types.Error {
+		rosErr := new(types.Error)
+		if postJSON(t, srv.Handler(), "/network/status", &types.NetworkRequest{NetworkIdentifier: client.Network()}, rosErr) == http.StatusOK {
+			return nil
+		}
+		return rosErr
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+		// wantNetworkStatus is the error code of /network/status after the request, -1 if it succeeds
+		wantNetworkStatus int32
+	}{
+		{name: "missing token", method: http.MethodGet, path: "/maintenance", wantStatus: http.StatusUnauthorized, wantNetworkStatus: -1},
+		{name: "wrong token", method: http.MethodGet, path: "/maintenance", token: "wrong", wantStatus: http.StatusUnauthorized, wantNetworkStatus: -1},
+		{name: "maintenance status", method: http.MethodGet, path: "/maintenance", token: adminToken, wantStatus: http.StatusOK, wantBody: `{"enabled":false}`, wantNetworkStatus: -1},
+		{name: "enable maintenance", method: http.MethodPost, path: "/maintenance", token: adminToken, body: `{"enabled":true}`, wantStatus: http.StatusOK, wantBody: `{"enabled":true}`, wantNetworkStatus: 503},
+		{name: "disable maintenance", method: http.MethodPost, path: "/maintenance", token: adminToken, body: `{"enabled":false}`, wantStatus: http.StatusOK, wantBody: `{"enabled":false}`, wantNetworkStatus: -1},
+		{name: "unknown field", method: http.MethodPost, path: "/maintenance", token: adminToken, body: `{"on":true}`, wantStatus: http.StatusBadRequest, wantNetworkStatus: -1},
+		{name: "method not allowed", method: http.MethodDelete, path: "/maintenance", token: adminToken, wantStatus: http.StatusMethodNotAllowed, wantNetworkStatus: -1},
+		{name: "offline mode", method: http.MethodPost, path: "/mode", token: adminToken, body: `{"mode":"offline"}`, wantStatus: http.StatusOK, wantBody: `{"mode":"offline"}`, wantNetworkStatus: 1},
+		{name: "invalid mode", method: http.MethodPost, path: "/mode", token: adminToken, body: `{"mode":"sleeping"}`, wantStatus: http.StatusBadRequest, wantNetworkStatus: 1},
+		{name: "online mode", method: http.MethodPost, path: "/mode", token: adminToken, body: `{"mode":"online"}`, wantStatus: http.StatusOK, wantBody: `{"mode":"online"}`, wantNetworkStatus: -1},
+		{name: "flush caches without client caches", method: http.MethodPost, path: "/caches/flush", token: adminToken, wantStatus: http.StatusNoContent, wantNetworkStatus: -1},
+		{name: "nodes unsupported", method: http.MethodGet, path: "/nodes", token: adminToken, wantStatus: http.StatusNotImplemented, wantNetworkStatus: -1},
+		{name: "config redacts secrets", method: http.MethodGet, path: "/config", token: adminToken, wantStatus: http.StatusOK, wantBody: `"client_config":{"api_key":"[REDACTED]","endpoint":"localhost:9090"}`, wantNetworkStatus: -1},
+		{name: "timeouts", method: http.MethodGet, path: "/timeouts", token: adminToken, wantStatus: http.StatusOK, wantBody: `"/block":0`, wantNetworkStatus: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			admin.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, rec.Body.String())
+			}
+			rosErr := networkStatus()
+			switch {
+			case tt.wantNetworkStatus == -1 && rosErr != nil:
+				t.Errorf("unexpected /network/status error %d (%s)", rosErr.Code, rosErr.Message)
+			case tt.wantNetworkStatus != -1 && (rosErr == nil || rosErr.Code != tt.wantNetworkStatus):
+				t.Errorf("expected /network/status error %d, got %v", tt.wantNetworkStatus, rosErr)
+			}
+		})
+	}
+
+	if strings.Contains(adminGet(t, admin, "/config"), adminToken) {
+		t.Fatal("the admin token is not redacted")
+	}
+}
+
+func TestMaintenanceHTTPStatus(t *testing.T) {
+	for _, fromErrors := range []bool{false, true} {
+		client := clienttest.NewClient(clienttest.Config{})
+		srv, err := server.NewServer(server.Settings{Network: client.Network(), Client: client, Retries: 1, HTTPStatusFromErrors: fromErrors})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.SetMaintenance(true)
+		want := http.StatusInternalServerError
+		if fromErrors {
+			want = http.StatusServiceUnavailable
+		}
+		rosErr := new(types.Error)
+		if got := postJSON(t, srv.Handler(), "/network/list", struct{}{}, rosErr); got != want {
+			t.Errorf("http status from errors %t: expected status %d, got %d", fromErrors, want, got)
+		}
+		if rosErr.Code != crgerrs.ToRosetta(crgerrs.ErrMaintenance).Code || !rosErr.Retriable {
+			t.Errorf("expected the retriable maintenance error, got %+v", rosErr)
+		}
+	}
+}
+
+func TestSetOfflineDoesNotBlockReloads(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	client.SetReady(errors.New("node not ready"))
+	settings := server.Settings{Network: client.Network(), Client: client, Offline: true, Retries: 10000, RetryWait: time.Millisecond}
+	srv, err := server.NewServer(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the online adapter waits for the node while the settings are reloaded
+	switched := make(chan error, 1)
+	go func() { switched <- srv.SetOffline(false) }()
+	settings.InlineTransactionsLimit = 1
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- srv.Reload(settings) }()
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload blocked by the online adapter construction")
+	}
+
+	client.SetReady(nil)
+	if err := <-switched; err != nil {
+		t.Fatal(err)
+	}
+
+	// the online adapter built with the previous settings got the reloaded ones
+	alice := clienttest.NewAccount("alice")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Transfer(alice, clienttest.NewAccount("bob").Address, big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := client.CommitBlock()
+	resp := new(types.BlockResponse)
+	postJSON(t, srv.Handler(), "/block", &types.BlockRequest{
+		NetworkIdentifier: client.Network(),
+		BlockIdentifier:   &types.PartialBlockIdentifier{Index: &block.Index},
+	}, resp)
+	if len(resp.Block.Transactions) != 1 || len(resp.OtherTransactions) != 1 {
+		t.Fatalf("expected the reloaded inline transactions limit, got %d inline and %d other transactions", len(resp.Block.Transactions), len(resp.OtherTransactions))
+	}
+}
+
+// flushClient is a clienttest client without TxBlockProvider implementing CacheFlusher,
+// it counts the block transactions queries and the flushes
+type flushClient struct {
+	crgtypes.Client
+	scans   int32
+	flushes int32
+}
+
+func (c *flushClient) BlockTransactionsByHeight(ctx context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	atomic.AddInt32(&c.scans, 1)
+	return c.Client.BlockTransactionsByHeight(ctx, height)
+}
+
+func (c *flushClient) FlushCaches() error {
+	atomic.AddInt32(&c.flushes, 1)
+	return nil
+}
+
+func TestAdminFlushCaches(t *testing.T) {
+	chain := clienttest.NewClient(clienttest.Config{})
+	client := &flushClient{Client: chain}
+	srv, err := server.NewServer(server.Settings{
+		Network:                 chain.Network(),
+		Client:                  client,
+		Retries:                 1,
+		AdminListen:             "localhost:0",
+		AdminToken:              adminToken,
+		InlineTransactionsLimit: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := clienttest.NewAccount("alice")
+	chain.SetBalance(alice.Address, big.NewInt(100))
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Transfer(alice, clienttest.NewAccount("bob").Address, big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := chain.CommitBlock()
+	resp := new(types.BlockResponse)
+	postJSON(t, srv.Handler(), "/block", &types.BlockRequest{
+		NetworkIdentifier: chain.Network(),
+		BlockIdentifier:   &types.PartialBlockIdentifier{Index: &block.Index},
+	}, resp)
+	if len(resp.OtherTransactions) != 1 {
+		t.Fatalf("expected 1 other transaction, got %d", len(resp.OtherTransactions))
+	}
+	// blockTransaction gets the other transaction and returns the block queries it made
+	blockTransaction := func() int32 {
+		before := atomic.LoadInt32(&client.scans)
+		if status := postJSON(t, srv.Handler(), "/block/transaction", &types.BlockTransactionRequest{
+			NetworkIdentifier:     chain.Network(),
+			BlockIdentifier:       block,
+			TransactionIdentifier: resp.OtherTransactions[0],
+		}, nil); status != http.StatusOK {
+			t.Fatalf("unexpected HTTP status %d", status)
+		}
+		return atomic.LoadInt32(&client.scans) - before
+	}
+
+	if n := blockTransaction(); n != 0 {
+		t.Fatalf("expected the block to be cached, got %d block queries", n)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/caches/flush", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected HTTP status %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	if atomic.LoadInt32(&client.flushes) != 1 {
+		t.Fatal("expected the client caches to be flushed")
+	}
+	if n := blockTransaction(); n != 1 {
+		t.Fatalf("expected the block to be queried again after the flush, got %d block queries", n)
+	}
+}
+
+// adminGet performs an authenticated GET on the admin API and returns the body
+func adminGet(t *testing.T, admin http.Handler, path string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, req)
+	var b bytes.Buffer
+	if err := json.Compact(&b, rec.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// ClientFactory builds the clients of a named implementation,
+// multiple factories can share the same name as long as they support
+// different cosmos-sdk versions.
+type ClientFactory struct {
+	// Name identifies the client implementation
+	Name string
+	// SDKVersions is the constraint on the cosmos-sdk versions supported by the factory,
+	// such as ">=0.45.0 <0.47.0", an empty constraint matches every version
+	SDKVersions string
+	// NewConfig returns a pointer to the client configuration filled with its defaults,
+	// the raw client configuration is decoded into it using its json tags.
+	// If nil the client receives no configuration.
+	NewConfig func() interface{}
+	// New builds the client given the configuration returned by NewConfig,
+	// exactly one of New and NewV2 must be set
+	New func(config interface{}) (crgtypes.Client, error)
+	// NewV2 builds the context aware client given the configuration returned by NewConfig
+	NewV2 func(config interface{}) (crgtypes.ClientV2, error)
+	// ErrorRegistry returns the registry containing the errors of the client, built with
+	// errors.NewRegistry, it must return the same registry on every call. It is used
+	// when the settings provide no registry, if nil the errors default registry is used.
+	ErrorRegistry func() *crgerrs.Registry
+}
+
+// registeredFactory is a factory with its parsed version constraint
+type registeredFactory struct {
+	ClientFactory
+	versions versionConstraint
+}
+
+var (
+	clientsMu sync.RWMutex
+	clients   = make(map[string][]registeredFactory)
+)
+
+// RegisterClient makes a client implementation available by name, it is meant
+// to be called from the init function of the package implementing the client.
+// It panics if the factory is invalid or if its versions overlap with a factory
+// registered with the same name.
+func RegisterClient(factory ClientFactory) {
+	if factory.Name == "" || (factory.New == nil) == (factory.NewV2 == nil) {
+		panic("rosetta: client factory name and exactly one of New and NewV2 are required")
+	}
+	versions, err := parseVersionConstraint(factory.SDKVersions)
+	if err != nil {
+		panic(fmt.Sprintf("rosetta: client %s: %s", factory.Name, err))
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, registered := range clients[factory.Name] {
+		if registered.versions.overlaps(versions) {
+			panic(fmt.Sprintf("rosetta: client %s registered twice for versions %q and %q", factory.Name, registered.SDKVersions, factory.SDKVersions))
+		}
+	}
+	clients[factory.Name] = append(clients[factory.Name], registeredFactory{ClientFactory: factory, versions: versions})
+}
+
+// NewClient builds the client registered with the given name supporting the cosmos-sdk version,
+// the version can be empty if only one factory is registered with the name.
+// The raw configuration is decoded into the factory configuration.
+// Clients registered with ClientFactory.NewV2 are built with NewClientV2.
+func NewClient(name string, sdkVersion string, rawConfig map[string]interface{}) (crgtypes.Client, error) {
+	factory, config, err := factoryAndConfig(name, sdkVersion, rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	if factory.New == nil {
+		return nil, fmt.Errorf("client %s is context aware, use NewClientV2", name)
+	}
+	return factory.New(config)
+}
+
+// NewClientV2 builds the client as NewClient, clients registered with ClientFactory.New are adapted
+// with types.AdaptClient so that clients registered with either constructor can be built
+func NewClientV2(name string, sdkVersion string, rawConfig map[string]interface{}) (crgtypes.ClientV2, error) {
+	client, _, err := newClient(name, sdkVersion, rawConfig)
+	return client, err
+}
+
+// newClient builds the context aware client and returns the factory used to build it
+func newClient(name string, sdkVersion string, rawConfig map[string]interface{}) (crgtypes.ClientV2, registeredFactory, error) {
+	factory, config, err := factoryAndConfig(name, sdkVersion, rawConfig)
+	if err != nil {
+		return nil, registeredFactory{}, err
+	}
+	if factory.NewV2 != nil {
+		client, err := factory.NewV2(config)
+		if err != nil {
+			return nil, registeredFactory{}, err
+		}
+		return client, factory, nil
+	}
+	client, err := factory.New(config)
+	if err != nil {
+		return nil, registeredFactory{}, err
+	}
+	return crgtypes.AdaptClient(client), factory, nil
+}
+
+// factoryAndConfig returns the factory registered with name supporting the version and its configuration
+func factoryAndConfig(name string, sdkVersion string, rawConfig map[string]interface{}) (registeredFactory, interface{}, error) {
+	factory, err := clientFactory(name, sdkVersion)
+	if err != nil {
+		return registeredFactory{}, nil, err
+	}
+	config, err := factory.config(rawConfig)
+	if err != nil {
+		return registeredFactory{}, nil, err
+	}
+	return factory, config, nil
+}
+
+// errorRegistry returns the registry of the factory, nil if it provides none
+func (f registeredFactory) errorRegistry() *crgerrs.Registry {
+	if f.ErrorRegistry == nil {
+		return nil
+	}
+	return f.ErrorRegistry()
+}
+
+// ClientErrorRegistry returns the registry of the errors the client registered with the given name
+// supporting the cosmos-sdk version can return, which is the errors default registry if the
+// factory provides none. The version can be empty if only one factory is registered with the name.
+func ClientErrorRegistry(name string, sdkVersion string) (*crgerrs.Registry, error) {
+	factory, err := clientFactory(name, sdkVersion)
+	if err != nil {
+		return nil, err
+	}
+	if registry := factory.errorRegistry(); registry != nil {
+		return registry, nil
+	}
+	return crgerrs.DefaultRegistry(), nil
+}
+
+// config returns the factory configuration given the raw configuration
+func (f registeredFactory) config(rawConfig map[string]interface{}) (interface{}, error) {
+	if f.NewConfig == nil {
+		return nil, nil
+	}
+	config := f.NewConfig()
+	if err := decodeClientConfig(rawConfig, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration for client %s: %w", f.Name, err)
+	}
+	return config, nil
+}
+
+// clientFactory returns the factory registered with name supporting the version
+func clientFactory(name string, sdkVersion string) (registeredFactory, error) {
+	clientsMu.RLock()
+	factories, ok := clients[name]
+	clientsMu.RUnlock()
+	if !ok {
+		return registeredFactory{}, fmt.Errorf("unknown client %q, available clients: %v", name, Clients())
+	}
+
+	if sdkVersion == "" {
+		if len(factories) != 1 {
+			return registeredFactory{}, fmt.Errorf("client %s supports multiple cosmos-sdk versions, a version is required", name)
+		}
+		return factories[0], nil
+	}
+
+	v, err := parseVersion(sdkVersion)
+	if err != nil {
+		return registeredFactory{}, err
+	}
+	for _, factory := range factories {
+		if factory.versions.matches(v) {
+			return factory, nil
+		}
+	}
+	return registeredFactory{}, fmt.Errorf("client %s does not support cosmos-sdk version %s", name, sdkVersion)
+}
+
+// decodeClientConfig decodes the raw configuration into config, unknown fields are rejected
+func decodeClientConfig(raw map[string]interface{}, config interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	bz, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(bz))
+	dec.DisallowUnknownFields()
+	return dec.Decode(config)
+}
+
+// Clients lists the names of the registered clients
+func Clients() []string {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+func TestClientErrorRegistry(t *testing.T) {
+	clientRegistry := crgerrs.NewRegistry()
+	clientErr, err := clientRegistry.Register(9600, "client error", false, "registered by the client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newNilClient := func(interface{}) (crgtypes.Client, error) { return nil, nil }
+	name := uniqueClientName("errors-test")
+	RegisterClient(ClientFactory{Name: name, SDKVersions: "<0.46.0", New: newNilClient, ErrorRegistry: func() *crgerrs.Registry { return clientRegistry }})
+	RegisterClient(ClientFactory{Name: name, SDKVersions: ">=0.46.0", New: newNilClient})
+
+	tests := []struct {
+		name       string
+		client     string
+		sdkVersion string
+		want       *crgerrs.Registry
+		wantErr    bool
+	}{
+		{name: "client registry", client: name, sdkVersion: "0.45.1", want: clientRegistry},
+		{name: "default registry", client: name, sdkVersion: "0.46.0", want: crgerrs.DefaultRegistry()},
+		{name: "version required", client: name, wantErr: true},
+		{name: "unknown client", client: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClientErrorRegistry(tt.client, tt.sdkVersion)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Fatalf("unexpected registry")
+			}
+		})
+	}
+
+	found := false
+	for _, e := range clientRegistry.Catalog() {
+		found = found || e.Code == crgerrs.ToRosetta(clientErr).Code
+	}
+	if !found {
+		t.Fatal("client error missing from the catalog")
+	}
+}
+
+// v1Client and v2Client are client stubs, their methods are not called
+type (
+	v1Client struct{ crgtypes.Client }
+	v2Client struct{ crgtypes.ClientV2 }
+)
+
+func TestClientFactoryV2(t *testing.T) {
+	v1, v2 := &v1Client{}, &v2Client{}
+	v1Name, v2Name := uniqueClientName("v1-test"), uniqueClientName("v2-test")
+	RegisterClient(ClientFactory{Name: v1Name, New: func(interface{}) (crgtypes.Client, error) { return v1, nil }})
+	RegisterClient(ClientFactory{Name: v2Name, NewV2: func(interface{}) (crgtypes.ClientV2, error) { return v2, nil }})
+
+	if client, err := NewClient(v1Name, "", nil); err != nil || client != v1 {
+		t.Errorf("NewClient(v1): got %v, %v", client, err)
+	}
+	if _, err := NewClient(v2Name, "", nil); err == nil {
+		t.Error("NewClient(v2): expected an error")
+	}
+	if client, err := NewClientV2(v2Name, "", nil); err != nil || client != v2 {
+		t.Errorf("NewClientV2(v2): got %v, %v", client, err)
+	}
+	client, err := NewClientV2(v1Name, "", nil)
+	if err != nil || crgtypes.UnwrapClient(client) != v1 {
+		t.Errorf("NewClientV2(v1): expected the adapted v1 client, got %v, %v", client, err)
+	}
+
+	for name, factory := range map[string]ClientFactory{
+		"no constructor": {Name: uniqueClientName("invalid-test")},
+		"both constructors": {
+			Name:  uniqueClientName("invalid-test"),
+			New:   func(interface{}) (crgtypes.Client, error) { return v1, nil },
+			NewV2: func(interface{}) (crgtypes.ClientV2, error) { return v2, nil },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected RegisterClient to panic")
+				}
+			}()
+			RegisterClient(factory)
+		})
+	}
+}
+
+// registeredTestClients counts the clients registered by the tests
+var registeredTestClients int32
+
+// uniqueClientName returns a client name not registered yet, so that the tests can run multiple times
+func uniqueClientName(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, atomic.AddInt32(&registeredTestClients, 1))
+}
@@ -0,0 +1,106 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+)
+
+func TestHTTPStatusMiddleware(t *testing.T) {
+	registry := crgerrs.NewRegistry()
+	clientErr, err := registry.RegisterWithHTTPStatus(9700, "client error", false, "", http.StatusConflict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rosettaError writes the error as the rosetta controllers do, with HTTP status 500
+	rosettaError := func(err *crgerrs.Error) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(crgerrs.ToRosetta(err))
+		})
+	}
+	unregistered := &types.Error{Code: 9999, Message: "unregistered"}
+
+	tests := []struct {
+		name       string
+		disabled   bool
+		handler    http.Handler
+		wantStatus int
+		wantCode   int32
+	}{
+		{name: "bad request", handler: rosettaError(crgerrs.ErrBadArgument), wantStatus: http.StatusBadRequest, wantCode: 400},
+		{name: "service unavailable", handler: rosettaError(crgerrs.ErrBadGateway), wantStatus: http.StatusServiceUnavailable, wantCode: 502},
+		{name: "not implemented", handler: rosettaError(crgerrs.ErrOffline), wantStatus: http.StatusNotImplemented, wantCode: 1},
+		{name: "client error", handler: rosettaError(clientErr), wantStatus: http.StatusConflict, wantCode: 9700},
+		{
+			name: "unregistered code",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(unregistered)
+			}),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   9999,
+		},
+		{
+			name: "not a rosetta error",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "failure", http.StatusInternalServerError)
+			}),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("{}"))
+			}),
+			wantStatus: http.StatusOK,
+		},
+		{name: "disabled", disabled: true, handler: rosettaError(crgerrs.ErrBadArgument), wantStatus: http.StatusInternalServerError, wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newRuntimeSettings(Settings{HTTPStatusFromErrors: !tt.disabled})
+			rec := httptest.NewRecorder()
+			httpStatusMiddleware(rt, registry, tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/block", strings.NewReader("{}")))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected HTTP status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantCode == 0 {
+				return
+			}
+			// the body is forwarded as is
+			rosErr := new(types.Error)
+			if err := json.Unmarshal(rec.Body.Bytes(), rosErr); err != nil {
+				t.Fatalf("cannot decode %q: %v", rec.Body.String(), err)
+			}
+			if rosErr.Code != tt.wantCode {
+				t.Fatalf("expected error code %d, got %d", tt.wantCode, rosErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+)
+
+// submitPath is the path of the rosetta submit endpoint
+const submitPath = "/construction/submit"
+
+// requestMetadataMiddleware extracts the metadata field from the body of requests
+// whose rosetta type does not define one, and forwards it to the service via context
+func requestMetadataMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != submitPath || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// decoding errors are left to the rosetta controller
+		var req struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		if err := json.Unmarshal(body, &req); err == nil && req.Metadata != nil {
+			r = r.WithContext(service.WithRequestMetadata(r.Context(), req.Metadata))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpStatusMiddleware replaces the HTTP 500 status the rosetta controllers use for every
+// error with the HTTP status of the returned error, as registered in the registry,
+// when HTTPStatusFromErrors is enabled
+func httpStatusMiddleware(rt *runtimeSettings, registry *crgerrs.Registry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rt.load().HTTPStatusFromErrors {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &errorStatusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if !rec.intercepted {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		rosErr := new(types.Error)
+		if err := json.Unmarshal(rec.body.Bytes(), rosErr); err == nil {
+			status = registry.HTTPStatus(rosErr.Code)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// errorStatusRecorder buffers the responses with HTTP status 500
+// so that their status can be replaced once the error is known
+type errorStatusRecorder struct {
+	http.ResponseWriter
+	intercepted bool
+	body        bytes.Buffer
+}
+
+func (w *errorStatusRecorder) WriteHeader(status int) {
+	if status == http.StatusInternalServerError {
+		w.intercepted = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *errorStatusRecorder) Write(b []byte) (int, error) {
+	if w.intercepted {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// maxBodyMiddleware limits the size of the request bodies to MaxRequestBodyBytes
+func maxBodyMiddleware(rt *runtimeSettings, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limit := rt.load().MaxRequestBodyBytes; limit > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitMiddleware makes every endpoint return ErrResourceExhausted when the requests
+// exceed RateLimit, the limit applies to the requests of all the clients together
+func rateLimitMiddleware(rt *runtimeSettings, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := rt.load()
+		if settings.RateLimit > 0 && !rt.limiter.allow(settings.RateLimit, settings.rateLimitBurst(), time.Now()) {
+			server.EncodeJSONResponse(crgerrs.ToRosetta(crgerrs.WrapError(crgerrs.ErrResourceExhausted, "rate limit exceeded")), http.StatusInternalServerError, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimiter is a token bucket, the rate and burst are provided on every call
+// so that they can be changed at runtime
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// allow reports if a request can be served at now, consuming a token if so
+func (l *rateLimiter) allow(rate float64, burst int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last.IsZero() {
+		l.tokens = float64(burst)
+	} else {
+		l.tokens = math.Min(float64(burst), l.tokens+now.Sub(l.last).Seconds()*rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// maintenanceMiddleware makes every endpoint return ErrMaintenance while the server is in maintenance mode
+func maintenanceMiddleware(rt *runtimeSettings, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt.inMaintenance() {
+			server.EncodeJSONResponse(crgerrs.ToRosetta(crgerrs.ErrMaintenance), http.StatusInternalServerError, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoveryMiddleware turns handler panics into ErrInternal responses, logging the panic and its stack trace
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to abort responses silently
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			stack := debug.Stack()
+			// panics of endpoint calls run with a timeout carry the stack of the goroutine running the call
+			if p, ok := rec.(*service.Panic); ok {
+				stack = p.Stack
+			}
+			logging.Errorf("panic serving %s: %v\n%s", r.URL.Path, rec, stack)
+			server.EncodeJSONResponse(crgerrs.ToRosetta(crgerrs.ErrInternal), http.StatusInternalServerError, w)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,97 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+)
+
+// broadcastClient is a clienttest client accepting any transaction without adding it to the mempool,
+// so that waiting for its inclusion always times out
+type broadcastClient struct {
+	*clienttest.Client
+}
+
+func (broadcastClient) PostTx(context.Context, []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	return &types.TransactionIdentifier{Hash: "ABCD"}, nil, nil
+}
+
+func TestRequestMetadataMiddleware(t *testing.T) {
+	client := broadcastClient{clienttest.NewClient(clienttest.Config{})}
+	srv, err := server.NewServer(server.Settings{Network: client.Network(), Client: client, Retries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		meta         map[string]interface{}
+		wantErr      *crgerrs.Error
+		wantIncluded interface{}
+	}{
+		{name: "no metadata"},
+		{
+			name:         "forwarded metadata",
+			meta:         map[string]interface{}{service.SubmitWaitForInclusion: true, service.SubmitInclusionTimeout: "10ms"},
+			wantIncluded: false,
+		},
+		{
+			name:    "invalid metadata",
+			meta:    map[string]interface{}{service.SubmitInclusionTimeout: "soon"},
+			wantErr: crgerrs.ErrBadArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := struct {
+				types.ConstructionSubmitRequest
+				Metadata map[string]interface{} `json:"metadata,omitempty"`
+			}{
+				ConstructionSubmitRequest: types.ConstructionSubmitRequest{NetworkIdentifier: client.Network(), SignedTransaction: "00"},
+				Metadata:                  tt.meta,
+			}
+			var resp struct {
+				types.TransactionIdentifierResponse
+				types.Error
+			}
+			postJSON(t, srv.Handler(), "/construction/submit", req, &resp)
+
+			if tt.wantErr != nil {
+				if resp.Code != crgerrs.ToRosetta(tt.wantErr).Code {
+					t.Fatalf("expected error %d, got %+v", crgerrs.ToRosetta(tt.wantErr).Code, resp.Error)
+				}
+				return
+			}
+			// the body read by the middleware is still decoded by the rosetta controller
+			if resp.TransactionIdentifier == nil || resp.TransactionIdentifier.Hash != "ABCD" {
+				t.Fatalf("expected the broadcast transaction, got %+v", resp)
+			}
+			if got := resp.TransactionIdentifierResponse.Metadata[service.SubmitMetaIncluded]; got != tt.wantIncluded {
+				t.Fatalf("expected included %v, got %v", tt.wantIncluded, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
+)
+
+// ReloadableClient is implemented by clients which can apply a new configuration at runtime,
+// such as a different set of upstream nodes. The configuration is the one returned by the
+// NewConfig function of the client factory.
+type ReloadableClient interface {
+	Reload(config interface{}) error
+}
+
+// optionsUpdater is implemented by the adapters supporting runtime options updates
+type optionsUpdater interface {
+	UpdateOptions(opts service.Options) error
+}
+
+// runtimeSettings holds the settings and state of a running server, which can be updated atomically
+type runtimeSettings struct {
+	v atomic.Value
+	// mu serializes the reloads
+	mu sync.Mutex
+	// maintenance is set to 1 when the server is in maintenance mode
+	maintenance int32
+	// limiter enforces the rate limit of the settings
+	limiter rateLimiter
+}
+
+func newRuntimeSettings(settings Settings) *runtimeSettings {
+	rt := new(runtimeSettings)
+	rt.v.Store(settings)
+	return rt
+}
+
+func (rt *runtimeSettings) load() Settings {
+	return rt.v.Load().(Settings)
+}
+
+func (rt *runtimeSettings) inMaintenance() bool {
+	return atomic.LoadInt32(&rt.maintenance) == 1
+}
+
+func (rt *runtimeSettings) setMaintenance(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&rt.maintenance, v)
+}
+
+// Reload applies the new settings to the running server. Only the following settings
+// can change at runtime: gas prices, gas adjustment, round trip verification, debug mode,
+// HTTP statuses from errors, maximum request body size, endpoint timeouts, inline transactions limit,
+// rate limit, log level and the client configuration,
+// when the client implements ReloadableClient. If any other setting differs from the
+// current one the reload is rejected and nothing is applied.
+func (h Server) Reload(settings Settings) error {
+	h.runtime.mu.Lock()
+	defer h.runtime.mu.Unlock()
+
+	current := h.runtime.load()
+	if settings.Client == nil && settings.ClientV2 == nil {
+		settings.Client = current.Client
+	}
+	if settings.ClientV2 == nil && settings.Client == current.Client {
+		settings.ClientV2 = current.ClientV2
+	}
+	if settings.ErrorRegistry == nil && h.factory != nil {
+		settings.ErrorRegistry = h.factory.errorRegistry()
+	}
+	if changed := nonReloadableChanges(current, settings); len(changed) != 0 {
+		return fmt.Errorf("settings cannot be changed at runtime: %s", strings.Join(changed, ", "))
+	}
+
+	if err := settings.validateRateLimit(); err != nil {
+		return err
+	}
+	logLevel, err := settings.logLevel()
+	if err != nil {
+		return err
+	}
+	opts := settings.serviceOptions()
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	var (
+		reloadable   ReloadableClient
+		clientConfig interface{}
+	)
+	if !reflect.DeepEqual(current.ClientConfig, settings.ClientConfig) {
+		var ok bool
+		reloadable, ok = settings.capabilities().(ReloadableClient)
+		if !ok || h.factory == nil {
+			return fmt.Errorf("settings cannot be changed at runtime: client config, the client does not support reloading")
+		}
+		var err error
+		clientConfig, err = h.factory.config(settings.ClientConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	// everything is validated, apply the adapters options first as they can be rolled back
+	// if the client rejects its new configuration
+	if err := h.adapters.updateOptions(opts); err != nil {
+		return h.rollbackOptions(current, err)
+	}
+	if reloadable != nil {
+		if err := reloadable.Reload(clientConfig); err != nil {
+			return h.rollbackOptions(current, fmt.Errorf("cannot reload client: %w", err))
+		}
+	}
+	logging.SetLevel(logLevel)
+	h.runtime.v.Store(settings)
+	return nil
+}
+
+// rollbackOptions restores the adapters options of the current settings after a failed reload
+func (h Server) rollbackOptions(current Settings, reloadErr error) error {
+	if err := h.adapters.updateOptions(current.serviceOptions()); err != nil {
+		return fmt.Errorf("%w, and the previous options could not be restored: %s", reloadErr, err)
+	}
+	return reloadErr
+}
+
+// nonReloadableChanges returns the names of the settings which differ
+// between current and next but cannot be changed at runtime
+func nonReloadableChanges(current, next Settings) []string {
+	var changed []string
+	check := func(name string, equal bool) {
+		if !equal {
+			changed = append(changed, name)
+		}
+	}
+	check("network", reflect.DeepEqual(current.Network, next.Network))
+	check("client", current.Client == next.Client && current.ClientV2 == next.ClientV2)
+	check("client name", current.ClientName == next.ClientName)
+	check("client sdk version", current.ClientSDKVersion == next.ClientSDKVersion)
+	check("listen", current.Listen == next.Listen)
+	check("offline", current.Offline == next.Offline)
+	check("error registry", current.errorRegistry() == next.errorRegistry())
+	check("tls", current.TLSCertFile == next.TLSCertFile && current.TLSKeyFile == next.TLSKeyFile)
+	check("timeouts", current.ReadTimeout == next.ReadTimeout && current.WriteTimeout == next.WriteTimeout && current.IdleTimeout == next.IdleTimeout)
+	check("max header bytes", current.MaxHeaderBytes == next.MaxHeaderBytes)
+	check("admin", current.AdminListen == next.AdminListen && current.AdminToken == next.AdminToken)
+	return changed
+}
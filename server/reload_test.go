@@ -0,0 +1,281 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+// reloadConfig is the configuration of reloadableClient
+type reloadConfig struct {
+	Endpoint string `json:"endpoint"`
+}
+
+// reloadableClient is a clienttest client implementing server.ReloadableClient,
+// reloads fail when the endpoint is "invalid"
+type reloadableClient struct {
+	*clienttest.Client
+	endpoint string
+}
+
+func (c *reloadableClient) Reload(config interface{}) error {
+	endpoint := config.(*reloadConfig).Endpoint
+	if endpoint == "invalid" {
+		return errors.New("invalid endpoint")
+	}
+	c.endpoint = endpoint
+	return nil
+}
+
+// reloadClients are the clients built by the reload-test factory
+var reloadClients = make(chan *reloadableClient, 1)
+
+func init() {
+	server.RegisterClient(server.ClientFactory{
+		Name:      "reload-test",
+		NewConfig: func() interface{} { return new(reloadConfig) },
+		New: func(config interface{}) (crgtypes.Client, error) {
+			c := &reloadableClient{Client: clienttest.NewClient(clienttest.Config{}), endpoint: config.(*reloadConfig).Endpoint}
+			reloadClients <- c
+			return c, nil
+		},
+	})
+}
+
+func TestReload(t *testing.T) {
+	settings := func(modify func(s *server.Settings)) server.Settings {
+		s := server.Settings{
+			Network:      clienttest.NewClient(clienttest.Config{}).Network(),
+			ClientName:   "reload-test",
+			ClientConfig: map[string]interface{}{"endpoint": "first"},
+			Retries:      1,
+		}
+		if modify != nil {
+			modify(&s)
+		}
+		return s
+	}
+	srv, err := server.NewServer(settings(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := <-reloadClients
+
+	// a block with two transactions to observe the inline transactions limit
+	alice := clienttest.NewAccount("alice")
+	client.SetBalance(alice.Address, big.NewInt(100))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Transfer(alice, clienttest.NewAccount("bob").Address, big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := client.CommitBlock()
+	inlineTxs := func() int {
+		resp := new(types.BlockResponse)
+		postJSON(t, srv.Handler(), "/block", &types.BlockRequest{
+			NetworkIdentifier: client.Network(),
+			BlockIdentifier:   &types.PartialBlockIdentifier{Index: &block.Index},
+		}, resp)
+		return len(resp.Block.Transactions)
+	}
+
+	tests := []struct {
+		name         string
+		settings     server.Settings
+		wantErr      bool
+		wantInline   int
+		wantEndpoint string
+	}{
+		{
+			name:         "options",
+			settings:     settings(func(s *server.Settings) { s.InlineTransactionsLimit = 1 }),
+			wantInline:   1,
+			wantEndpoint: "first",
+		},
+		{
+			name:         "non reloadable setting",
+			settings:     settings(func(s *server.Settings) { s.Listen = "localhost:1234" }),
+			wantErr:      true,
+			wantInline:   1,
+			wantEndpoint: "first",
+		},
+		{
+			name:         "invalid options",
+			settings:     settings(func(s *server.Settings) { s.GasAdjustment = -1 }),
+			wantErr:      true,
+			wantInline:   1,
+			wantEndpoint: "first",
+		},
+		{
+			name:         "invalid rate limit",
+			settings:     settings(func(s *server.Settings) { s.RateLimit = -1 }),
+			wantErr:      true,
+			wantInline:   1,
+			wantEndpoint: "first",
+		},
+		{
+			name:         "invalid log level",
+			settings:     settings(func(s *server.Settings) { s.LogLevel = "verbose" }),
+			wantErr:      true,
+			wantInline:   1,
+			wantEndpoint: "first",
+		},
+		{
+			name: "client config",
+			settings: settings(func(s *server.Settings) {
+				s.ClientConfig = map[string]interface{}{"endpoint": "second"}
+			}),
+			wantInline:   2,
+			wantEndpoint: "second",
+		},
+		{
+			name: "invalid client config",
+			settings: settings(func(s *server.Settings) {
+				s.ClientConfig = map[string]interface{}{"unknown": "field"}
+			}),
+			wantErr:      true,
+			wantInline:   2,
+			wantEndpoint: "second",
+		},
+		{
+			name: "client rejects its config, options are rolled back",
+			settings: settings(func(s *server.Settings) {
+				s.InlineTransactionsLimit = 1
+				s.ClientConfig = map[string]interface{}{"endpoint": "invalid"}
+			}),
+			wantErr:      true,
+			wantInline:   2,
+			wantEndpoint: "second",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := srv.Reload(tt.settings)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := inlineTxs(); got != tt.wantInline {
+				t.Errorf("expected %d inline transactions, got %d", tt.wantInline, got)
+			}
+			if client.endpoint != tt.wantEndpoint {
+				t.Errorf("expected client endpoint %q, got %q", tt.wantEndpoint, client.endpoint)
+			}
+		})
+	}
+}
+
+func TestReloadRateLimit(t *testing.T) {
+	client := clienttest.NewClient(clienttest.Config{})
+	settings := server.Settings{Network: client.Network(), Client: client, Retries: 1, HTTPStatusFromErrors: true}
+	srv, err := server.NewServer(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := func() int {
+		return postJSON(t, srv.Handler(), "/network/options", &types.NetworkRequest{NetworkIdentifier: client.Network()}, nil)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := status(); got != http.StatusOK {
+			t.Fatalf("expected no rate limit, got status %d", got)
+		}
+	}
+
+	settings.RateLimit = 0.001
+	settings.RateLimitBurst = 2
+	if err := srv.Reload(settings); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, crgerrs.ErrResourceExhausted.HTTPStatus()} {
+		if got := status(); got != want {
+			t.Fatalf("request %d: expected status %d, got %d", i, want, got)
+		}
+	}
+
+	settings.RateLimit = 0
+	if err := srv.Reload(settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != http.StatusOK {
+		t.Fatalf("expected the rate limit to be removed, got status %d", got)
+	}
+}
+
+func TestReloadLogLevel(t *testing.T) {
+	defer logging.SetLevel(logging.DefaultLevel)
+	client := clienttest.NewClient(clienttest.Config{})
+	settings := server.Settings{Network: client.Network(), Client: client, Retries: 1, LogLevel: "warn"}
+	srv, err := server.NewServer(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := logging.GetLevel(); got != logging.LevelWarn {
+		t.Fatalf("expected level warn at startup, got %s", got)
+	}
+
+	settings.LogLevel = "debug"
+	if err := srv.Reload(settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := logging.GetLevel(); got != logging.LevelDebug {
+		t.Fatalf("expected level debug after reload, got %s", got)
+	}
+
+	settings.LogLevel = "verbose"
+	if err := srv.Reload(settings); err == nil {
+		t.Fatal("expected invalid log level error")
+	}
+	if got := logging.GetLevel(); got != logging.LevelDebug {
+		t.Fatalf("expected the rejected reload to keep level debug, got %s", got)
+	}
+}
+
+// postJSON posts the request to the handler and decodes the response into resp if not nil,
+// it returns the HTTP status
+func postJSON(t *testing.T, h http.Handler, path string, req, resp interface{}) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	if resp != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+			t.Fatalf("cannot decode %s response %q: %v", path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
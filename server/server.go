@@ -19,13 +19,16 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	assert "github.com/coinbase/rosetta-sdk-go/asserter"
-	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/internal/logging"
 	"github.com/tendermint/cosmos-rosetta-gateway/internal/service"
 	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
 )
@@ -37,8 +40,19 @@ const DefaultRetryWait = 5 * time.Second
 type Settings struct {
 	// Network contains the information regarding the network
 	Network *types.NetworkIdentifier
-	// Client is the online API handler
+	// Client is the online API handler, if nil the client is built
+	// from the registered client factories given ClientName
 	Client crgtypes.Client
+	// ClientV2 is the context aware online API handler, it takes precedence over Client.
+	// If nil it is set to Client adapted with crgtypes.AdaptClient.
+	ClientV2 crgtypes.ClientV2
+	// ClientName is the name of the registered client implementation used when Client is nil
+	ClientName string
+	// ClientSDKVersion is the cosmos-sdk version of the node, it selects the client factory
+	// when multiple factories are registered under ClientName
+	ClientSDKVersion string
+	// ClientConfig is the raw configuration decoded into the client factory configuration
+	ClientConfig map[string]interface{}
 	// Listen is the address the handler will listen at
 	Listen string
 	// Offline defines if the rosetta service should be exposed in offline mode
@@ -48,20 +62,188 @@ type Settings struct {
 	Retries int
 	// RetryWait is the time that will be waited between retries
 	RetryWait time.Duration
+	// GasPrices are the gas prices used to compute the suggested fee of /construction/metadata,
+	// values are decimals expressed in the smallest unit of the currency
+	GasPrices []*types.Amount
+	// GasAdjustment is the factor applied to the simulated gas to obtain the gas limit
+	GasAdjustment float64
+	// VerifyRoundTrip enables the consistency check between the requested operations
+	// and the ones parsed from the transactions built in /construction/payloads and /construction/combine
+	VerifyRoundTrip bool
+	// ErrorRegistry contains the errors the network can return, if nil the registry
+	// of the client factory or the errors default registry is used. Each network in the same process
+	// can provide its own registry, built with errors.NewRegistry.
+	ErrorRegistry *crgerrs.Registry
+	// Debug makes errors include their cause chain, it should not be used in production
+	// as causes can leak internal information
+	Debug bool
+	// HTTPStatusFromErrors makes error responses use the HTTP status of the returned error
+	// instead of 500, disabled by default for strict rosetta compatibility
+	HTTPStatusFromErrors bool
+	// TLSCertFile and TLSKeyFile are the certificate and key used to serve HTTPS,
+	// if empty the server uses plain HTTP
+	TLSCertFile string
+	TLSKeyFile  string
+	// ReadTimeout, WriteTimeout and IdleTimeout are the HTTP server timeouts, zero means no timeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// MaxHeaderBytes is the maximum size of the request headers, zero means the net/http default
+	MaxHeaderBytes int
+	// MaxRequestBodyBytes is the maximum size of the request body, zero means no limit
+	MaxRequestBodyBytes int64
+	// AdminListen is the address the admin API listens at, if empty the admin API is disabled
+	AdminListen string
+	// AdminToken is the bearer token required by the admin API
+	AdminToken string
+	// EndpointTimeout is the time a rosetta endpoint has to complete before the retriable
+	// errors.ErrTimeout is returned, zero means no timeout
+	EndpointTimeout time.Duration
+	// EndpointTimeouts overrides EndpointTimeout per endpoint, keyed by path such as "/block"
+	EndpointTimeouts map[string]time.Duration
+	// InlineTransactionsLimit is the maximum number of transactions included in /block responses,
+	// the other ones are listed as other transactions. Zero means no limit.
+	InlineTransactionsLimit int
+	// RateLimit is the maximum number of requests per second served, across all clients,
+	// the requests exceeding it get the retriable errors.ErrResourceExhausted. Zero means no limit.
+	RateLimit float64
+	// RateLimitBurst is the number of requests which can exceed RateLimit at once,
+	// it defaults to RateLimit rounded up
+	RateLimitBurst int
+	// LogLevel is the minimum level of the logged messages among debug, info, warn and error,
+	// it defaults to info. The level is process wide.
+	LogLevel string
+}
+
+// errorRegistry returns the configured error registry or the default one
+func (s Settings) errorRegistry() *crgerrs.Registry {
+	if s.ErrorRegistry == nil {
+		return crgerrs.DefaultRegistry()
+	}
+	return s.ErrorRegistry
+}
+
+// validateRateLimit checks the rate limit settings are valid
+func (s Settings) validateRateLimit() error {
+	if s.RateLimit < 0 || s.RateLimitBurst < 0 {
+		return fmt.Errorf("rate limit and burst must not be negative")
+	}
+	return nil
+}
+
+// logLevel parses the configured log level
+func (s Settings) logLevel() (logging.Level, error) {
+	return logging.ParseLevel(s.LogLevel)
+}
+
+// rateLimitBurst returns the configured burst or its default
+func (s Settings) rateLimitBurst() int {
+	if s.RateLimitBurst > 0 {
+		return s.RateLimitBurst
+	}
+	return int(math.Ceil(s.RateLimit))
+}
+
+// capabilities returns the value the optional client capabilities are detected on
+func (s Settings) capabilities() interface{} {
+	return crgtypes.UnwrapClient(s.ClientV2)
+}
+
+// serviceOptions returns the service options given the settings
+func (s Settings) serviceOptions() service.Options {
+	return service.Options{
+		GasPrices:               s.GasPrices,
+		GasAdjustment:           s.GasAdjustment,
+		VerifyRoundTrip:         s.VerifyRoundTrip,
+		ErrorRegistry:           s.errorRegistry(),
+		Debug:                   s.Debug,
+		DefaultTimeout:          s.EndpointTimeout,
+		Timeouts:                s.EndpointTimeouts,
+		InlineTransactionsLimit: s.InlineTransactionsLimit,
+	}
 }
 
 type Server struct {
-	h    http.Handler
-	addr string
+	h        http.Handler
+	addr     string
+	settings Settings
+
+	adapters *adapterSwitch     // online and offline adapters
+	factory  *registeredFactory // factory of the client, nil if the client was provided
+	runtime  *runtimeSettings   // settings and state which can change at runtime
 }
 
+// Handler returns the handler serving the rosetta API, to embed the gateway in another HTTP server
+func (h Server) Handler() http.Handler {
+	return h.h
+}
+
+// Start serves the rosetta API and, if configured, the admin API.
+// It blocks until one of the listeners fails.
 func (h Server) Start() error {
-	return http.ListenAndServe(h.addr, h.h)
+	errs := make(chan error, 2)
+	go func() { errs <- h.listenAndServe(h.addr, h.h) }()
+	if h.settings.AdminListen != "" {
+		go func() { errs <- h.listenAndServe(h.settings.AdminListen, h.AdminHandler()) }()
+	}
+	return <-errs
 }
 
+func (h Server) listenAndServe(addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		ReadTimeout:    h.settings.ReadTimeout,
+		WriteTimeout:   h.settings.WriteTimeout,
+		IdleTimeout:    h.settings.IdleTimeout,
+		MaxHeaderBytes: h.settings.MaxHeaderBytes,
+	}
+	if h.settings.TLSCertFile != "" {
+		return srv.ListenAndServeTLS(h.settings.TLSCertFile, h.settings.TLSKeyFile)
+	}
+	return srv.ListenAndServe()
+}
+
+// NewServer builds the server, see NewServerContext
 func NewServer(settings Settings) (Server, error) {
+	return NewServerContext(context.Background(), settings)
+}
+
+// NewServerContext builds the server, in online mode it bootstraps the client and waits
+// for the node to be ready. The context bounds the startup: canceling it stops waiting for
+// the node and is passed to the client Bootstrap and Ready methods.
+func NewServerContext(ctx context.Context, settings Settings) (Server, error) {
+	var factory *registeredFactory
+	if settings.Client == nil && settings.ClientV2 == nil && settings.ClientName != "" {
+		client, f, err := newClient(settings.ClientName, settings.ClientSDKVersion, settings.ClientConfig)
+		if err != nil {
+			return Server{}, fmt.Errorf("cannot build client: %w", err)
+		}
+		settings.ClientV2 = client
+		factory = &f
+		if settings.ErrorRegistry == nil {
+			settings.ErrorRegistry = f.errorRegistry()
+		}
+	}
+	if settings.ClientV2 == nil && settings.Client != nil {
+		settings.ClientV2 = crgtypes.AdaptClient(settings.Client)
+	}
+	if settings.ClientV2 == nil {
+		return Server{}, fmt.Errorf("client is nil")
+	}
+	if err := settings.validateRateLimit(); err != nil {
+		return Server{}, err
+	}
+	logLevel, err := settings.logLevel()
+	if err != nil {
+		return Server{}, err
+	}
+	if settings.AdminListen != "" && settings.AdminToken == "" {
+		return Server{}, fmt.Errorf("admin token is required when the admin API is enabled")
+	}
+
 	asserter, err := assert.NewServer(
-		settings.Client.SupportedOperations(),
+		settings.ClientV2.SupportedOperations(ctx),
 		true,
 		[]*types.NetworkIdentifier{settings.Network},
 		nil,
@@ -71,41 +253,40 @@ func NewServer(settings Settings) (Server, error) {
 		return Server{}, fmt.Errorf("cannot build asserter: %w", err)
 	}
 
-	var (
-		adapter crgtypes.API
-	)
-	switch settings.Offline {
-	case true:
-		adapter, err = newOfflineAdapter(settings)
-	case false:
-		adapter, err = newOnlineAdapter(settings)
-	}
+	adapters, err := newAdapterSwitch(ctx, settings, asserter)
 	if err != nil {
 		return Server{}, err
 	}
-	h := server.NewRouter(
-		server.NewAccountAPIController(adapter, asserter),
-		server.NewBlockAPIController(adapter, asserter),
-		server.NewNetworkAPIController(adapter, asserter),
-		server.NewMempoolAPIController(adapter, asserter),
-		server.NewConstructionAPIController(adapter, asserter),
-	)
+	logging.SetLevel(logLevel)
+	rt := newRuntimeSettings(settings)
+	var h http.Handler = maintenanceMiddleware(rt, adapters)
+	h = rateLimitMiddleware(rt, h)
+	h = recoveryMiddleware(h)
+	h = httpStatusMiddleware(rt, settings.errorRegistry(), h)
+	h = requestMetadataMiddleware(h)
+	h = maxBodyMiddleware(rt, h)
 
 	return Server{
-		h:    h,
-		addr: settings.Listen,
+		h:        h,
+		addr:     settings.Listen,
+		settings: settings,
+		adapters: adapters,
+		factory:  factory,
+		runtime:  rt,
 	}, nil
 }
 
-func newOfflineAdapter(settings Settings) (crgtypes.API, error) {
-	if settings.Client == nil {
+func newOfflineAdapter(ctx context.Context, settings Settings) (crgtypes.API, error) {
+	if settings.ClientV2 == nil {
 		return nil, fmt.Errorf("client is nil")
 	}
-	return service.NewOffline(settings.Network, settings.Client)
+	return service.NewOffline(ctx, settings.Network, settings.ClientV2, settings.serviceOptions())
 }
 
-func newOnlineAdapter(settings Settings) (crgtypes.API, error) {
-	if settings.Client == nil {
+// newOnlineAdapter bootstraps the client and builds the online adapter once the node is ready,
+// waiting between the readiness checks is interrupted when ctx is done
+func newOnlineAdapter(ctx context.Context, settings Settings) (crgtypes.API, error) {
+	if settings.ClientV2 == nil {
 		return nil, fmt.Errorf("client is nil")
 	}
 	if settings.Retries <= 0 {
@@ -116,18 +297,24 @@ func newOnlineAdapter(settings Settings) (crgtypes.API, error) {
 	}
 
 	var err error
-	err = settings.Client.Bootstrap()
+	err = settings.ClientV2.Bootstrap(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := 0; i < settings.Retries; i++ {
-		err = settings.Client.Ready()
-		if err != nil {
-			time.Sleep(settings.RetryWait)
-			continue
+		err = settings.ClientV2.Ready(ctx)
+		if err == nil {
+			return service.NewOnlineNetwork(ctx, settings.Network, settings.ClientV2, settings.serviceOptions())
+		}
+		if i == settings.Retries-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("node not ready: %w, last error: %s", ctx.Err(), err)
+		case <-time.After(settings.RetryWait):
 		}
-		return service.NewOnlineNetwork(settings.Network, settings.Client)
 	}
 	return nil, fmt.Errorf("maximum number of retries exceeded, last error: %w", err)
 }
@@ -0,0 +1,150 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/tendermint/cosmos-rosetta-gateway/clienttest"
+	crgerrs "github.com/tendermint/cosmos-rosetta-gateway/errors"
+	"github.com/tendermint/cosmos-rosetta-gateway/server"
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+type ctxKey struct{}
+
+// ctxClient is a context aware clienttest client recording the context values seen by Bootstrap and Ready
+type ctxClient struct {
+	crgtypes.ClientV2
+	ready     error
+	bootstrap interface{}
+	readiness interface{}
+}
+
+func (c *ctxClient) Bootstrap(ctx context.Context) error {
+	c.bootstrap = ctx.Value(ctxKey{})
+	return nil
+}
+
+func (c *ctxClient) Ready(ctx context.Context) error {
+	c.readiness = ctx.Value(ctxKey{})
+	return c.ready
+}
+
+// ctxClients are the clients served by the context-test factory
+var ctxClients = make(chan *ctxClient, 1)
+
+func init() {
+	server.RegisterClient(server.ClientFactory{
+		Name:  "context-test",
+		NewV2: func(interface{}) (crgtypes.ClientV2, error) { return <-ctxClients, nil },
+	})
+}
+
+func TestNewServerContext(t *testing.T) {
+	newClient := func(ready error) (*ctxClient, *clienttest.Client) {
+		c := clienttest.NewClient(clienttest.Config{})
+		c.CommitBlock()
+		return &ctxClient{ClientV2: crgtypes.AdaptClient(c), ready: ready}, c
+	}
+
+	t.Run("context reaches the client", func(t *testing.T) {
+		client, c := newClient(nil)
+		ctx := context.WithValue(context.Background(), ctxKey{}, "startup")
+		_, err := server.NewServerContext(ctx, server.Settings{Network: c.Network(), ClientV2: client, Retries: 1, RetryWait: time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.bootstrap != "startup" || client.readiness != "startup" {
+			t.Fatalf("expected Bootstrap and Ready to get the startup context, got %v and %v", client.bootstrap, client.readiness)
+		}
+	})
+
+	t.Run("cancel while waiting for the node", func(t *testing.T) {
+		client, c := newClient(errors.New("node not ready"))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := server.NewServerContext(ctx, server.Settings{Network: c.Network(), ClientV2: client, Retries: 3, RetryWait: time.Hour})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Fatalf("startup was not interrupted, took %s", elapsed)
+		}
+	})
+
+	t.Run("context aware client factory", func(t *testing.T) {
+		client, c := newClient(nil)
+		ctxClients <- client
+		ctx := context.WithValue(context.Background(), ctxKey{}, "factory")
+		if _, err := server.NewServerContext(ctx, server.Settings{Network: c.Network(), ClientName: "context-test", Retries: 1, RetryWait: time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+		if client.bootstrap != "factory" {
+			t.Fatalf("expected the client built by the factory to be bootstrapped, got %v", client.bootstrap)
+		}
+	})
+}
+
+// panickingClient panics when queried for the sync status
+type panickingClient struct {
+	crgtypes.ClientV2
+}
+
+func (panickingClient) Status(context.Context) (*types.SyncStatus, error) {
+	panic("status panic")
+}
+
+func TestEndpointTimeoutPanicStack(t *testing.T) {
+	c := clienttest.NewClient(clienttest.Config{})
+	c.CommitBlock()
+	srv, err := server.NewServer(server.Settings{
+		Network:         c.Network(),
+		ClientV2:        panickingClient{crgtypes.AdaptClient(c)},
+		Retries:         1,
+		RetryWait:       time.Millisecond,
+		EndpointTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	resp := new(types.Error)
+	postJSON(t, srv.Handler(), "/network/status", &types.NetworkRequest{NetworkIdentifier: c.Network()}, resp)
+	if resp.Code != crgerrs.ToRosetta(crgerrs.ErrInternal).Code {
+		t.Fatalf("expected ErrInternal, got %+v", resp)
+	}
+	// the logged stack is the one of the goroutine running the endpoint call
+	if !strings.Contains(logs.String(), "panickingClient.Status") {
+		t.Fatalf("expected the logged stack to show the panicking call, got\n%s", logs.String())
+	}
+}
@@ -0,0 +1,151 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// version is a semantic version, pre-release and build metadata are ignored
+type version [3]uint64
+
+// parseVersion parses versions such as v0.45.4 or 0.46.0-rc1
+func parseVersion(s string) (version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return version{}, fmt.Errorf("invalid version %q", s)
+	}
+	var v version
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return version{}, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v version) compare(o version) int {
+	for i := range v {
+		switch {
+		case v[i] < o[i]:
+			return -1
+		case v[i] > o[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionConstraint is a version range, the bounds are inclusive
+// and a version matches if min <= version <= max
+type versionConstraint struct {
+	min, max version
+}
+
+// anyVersion matches every version
+var anyVersion = versionConstraint{max: version{math.MaxUint64, math.MaxUint64, math.MaxUint64}}
+
+// parseVersionConstraint parses space separated comparisons such as ">=0.45.0 <0.47.0",
+// the supported operators are =, >=, >, <=, <
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	c := anyVersion
+	for _, cmp := range strings.Fields(s) {
+		op := strings.TrimRight(cmp, "v0123456789.-+abcdefghijklmnopqrstuvwxyz")
+		v, err := parseVersion(cmp[len(op):])
+		if err != nil {
+			return versionConstraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		switch op {
+		case "=", "":
+			c.min, c.max = maxVersion(c.min, v), minVersion(c.max, v)
+		case ">=":
+			c.min = maxVersion(c.min, v)
+		case ">":
+			c.min = maxVersion(c.min, v.next())
+		case "<=":
+			c.max = minVersion(c.max, v)
+		case "<":
+			if v == (version{}) {
+				return versionConstraint{}, fmt.Errorf("version constraint %q matches no version", s)
+			}
+			c.max = minVersion(c.max, v.prev())
+		default:
+			return versionConstraint{}, fmt.Errorf("invalid version constraint %q: unknown operator %q", s, op)
+		}
+	}
+	if c.min.compare(c.max) > 0 {
+		return versionConstraint{}, fmt.Errorf("version constraint %q matches no version", s)
+	}
+	return c, nil
+}
+
+func (c versionConstraint) matches(v version) bool {
+	return c.min.compare(v) <= 0 && v.compare(c.max) <= 0
+}
+
+func (c versionConstraint) overlaps(o versionConstraint) bool {
+	return c.min.compare(o.max) <= 0 && o.min.compare(c.max) <= 0
+}
+
+// next returns the smallest version greater than v
+func (v version) next() version {
+	for i := 2; i >= 0; i-- {
+		if v[i] < math.MaxUint64 {
+			v[i]++
+			return v
+		}
+		v[i] = 0
+	}
+	return version{math.MaxUint64, math.MaxUint64, math.MaxUint64}
+}
+
+// prev returns the greatest version lower than v
+func (v version) prev() version {
+	for i := 2; i >= 0; i-- {
+		if v[i] > 0 {
+			v[i]--
+			return v
+		}
+		v[i] = math.MaxUint64
+	}
+	return version{}
+}
+
+func maxVersion(a, b version) version {
+	if a.compare(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func minVersion(a, b version) version {
+	if a.compare(b) <= 0 {
+		return a
+	}
+	return b
+}
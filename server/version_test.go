@@ -0,0 +1,166 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package server
+
+import (
+	"testing"
+
+	crgtypes "github.com/tendermint/cosmos-rosetta-gateway/types"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    version
+		wantErr bool
+	}{
+		{in: "v0.45.4", want: version{0, 45, 4}},
+		{in: "0.46.0-rc1", want: version{0, 46, 0}},
+		{in: " 1.2.3+build ", want: version{1, 2, 3}},
+		{in: "0.47", want: version{0, 47, 0}},
+		{in: "", wantErr: true},
+		{in: "0.45.4.1", wantErr: true},
+		{in: "0.x.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseVersion(tt.in)
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("%q: expected error", tt.in)
+		case !tt.wantErr && err != nil:
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+		case got != tt.want:
+			t.Errorf("%q: expected %v, got %v", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+		wantErr    bool
+	}{
+		{constraint: "", matches: []string{"0.0.0", "0.45.4", "99.0.0"}},
+		{constraint: ">=0.45.0 <0.47.0", matches: []string{"0.45.0", "0.46.99", "v0.46.0-rc1"}, rejects: []string{"0.44.9", "0.47.0"}},
+		{constraint: ">0.45.4 <=0.46.0", matches: []string{"0.45.5", "0.46.0"}, rejects: []string{"0.45.4", "0.46.1"}},
+		{constraint: "=0.45.4", matches: []string{"0.45.4"}, rejects: []string{"0.45.3", "0.45.5"}},
+		{constraint: "v0.45.4", matches: []string{"0.45.4"}, rejects: []string{"0.45.5"}},
+		{constraint: "<0.0.0", wantErr: true},
+		{constraint: ">=0.47.0 <0.46.0", wantErr: true},
+		{constraint: "~0.45.0", wantErr: true},
+		{constraint: ">=latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := parseVersionConstraint(tt.constraint)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.matches {
+				if !c.matches(mustParseVersion(t, s)) {
+					t.Errorf("expected %s to match", s)
+				}
+			}
+			for _, s := range tt.rejects {
+				if c.matches(mustParseVersion(t, s)) {
+					t.Errorf("expected %s not to match", s)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionConstraintOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"<0.46.0", ">=0.46.0", false},
+		{"<=0.46.0", ">=0.46.0", true},
+		{">=0.45.0 <0.47.0", "=0.46.3", true},
+		{"", "=0.46.3", true},
+		{"<0.45.0", ">0.46.0", false},
+	}
+	for _, tt := range tests {
+		a, err := parseVersionConstraint(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parseVersionConstraint(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.overlaps(b); got != tt.want || b.overlaps(a) != tt.want {
+			t.Errorf("%q and %q: expected overlap %t, got %t", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
+
+func TestClientFactoryVersionSelection(t *testing.T) {
+	newNilClient := func(interface{}) (crgtypes.Client, error) { return nil, nil }
+	name := uniqueClientName("versions-test")
+	RegisterClient(ClientFactory{Name: name, SDKVersions: "<0.46.0", New: newNilClient})
+	RegisterClient(ClientFactory{Name: name, SDKVersions: ">=0.46.0 <0.47.0", New: newNilClient})
+
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "v0.45.4", want: "<0.46.0"},
+		{version: "0.46.0-rc1", want: ">=0.46.0 <0.47.0"},
+		{version: "0.47.0", wantErr: true},
+		{version: "", wantErr: true},
+		{version: "invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		f, err := clientFactory(name, tt.version)
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("%q: expected error", tt.version)
+		case !tt.wantErr && err != nil:
+			t.Errorf("%q: unexpected error: %v", tt.version, err)
+		case f.SDKVersions != tt.want:
+			t.Errorf("%q: expected factory %q, got %q", tt.version, tt.want, f.SDKVersions)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected overlapping registration to panic")
+		}
+	}()
+	RegisterClient(ClientFactory{Name: name, SDKVersions: "=0.46.5", New: newNilClient})
+}
+
+func mustParseVersion(t *testing.T, s string) version {
+	v, err := parseVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,184 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package types
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// ClientV2 defines the API the client implementation should provide, as Client
+// does, with every method taking a context so that the cancellation, deadline and
+// tracing information of the request reach the client. Client implementations are
+// adapted with AdaptClient.
+type ClientV2 interface {
+	// Bootstrap is called once, before connecting to the node
+	Bootstrap(ctx context.Context) error
+	// Ready returns nil if the node is ready to serve queries
+	Ready(ctx context.Context) error
+
+	// Data API
+
+	// Balances fetches the balance of the given address at height, or at the last block if height is nil
+	Balances(ctx context.Context, addr string, height *int64) ([]*types.Amount, error)
+	// BlockByHash gets a block given its hash
+	BlockByHash(ctx context.Context, hash string) (BlockResponse, error)
+	// BlockByHeight gets a block given its height, if height is nil then last block is returned
+	BlockByHeight(ctx context.Context, height *int64) (BlockResponse, error)
+	// BlockTransactionsByHash gets the block, parent block and transactions given the block hash
+	BlockTransactionsByHash(ctx context.Context, hash string) (BlockTransactionsResponse, error)
+	// BlockTransactionsByHeight gets the block, parent block and transactions given the block height
+	BlockTransactionsByHeight(ctx context.Context, height *int64) (BlockTransactionsResponse, error)
+	// GetTx gets a transaction given its hash
+	GetTx(ctx context.Context, hash string) (*types.Transaction, error)
+	// Status returns the node status, such as sync data, version etc
+	Status(ctx context.Context) (*types.SyncStatus, error)
+
+	// Construction API
+
+	// PostTx posts txBytes to the node and returns the transaction identifier plus metadata related
+	// to the transaction itself
+	PostTx(ctx context.Context, txBytes []byte) (res *types.TransactionIdentifier, meta map[string]interface{}, err error)
+	// ConstructionMetadataFromOptions returns the construction metadata given the preprocess options
+	ConstructionMetadataFromOptions(ctx context.Context, options map[string]interface{}) (meta map[string]interface{}, err error)
+	OfflineClientV2
+}
+
+// NetworkInformationProviderV2 is NetworkInformationProvider with context
+type NetworkInformationProviderV2 interface {
+	// SupportedOperations lists the operations supported by the implementation
+	SupportedOperations(ctx context.Context) []string
+	// OperationStatuses returns the list of statuses supported by the implementation
+	OperationStatuses(ctx context.Context) []*types.OperationStatus
+	// Version returns the version of the node
+	Version(ctx context.Context) string
+}
+
+// OfflineClientV2 is OfflineClient with context
+type OfflineClientV2 interface {
+	NetworkInformationProviderV2
+	// SignedTx returns the signed transaction given the tx bytes (msgs) plus the signatures
+	SignedTx(ctx context.Context, txBytes []byte, sigs []*types.Signature) (signedTxBytes []byte, err error)
+	// TxOperationsAndSignersAccountIdentifiers returns the operations related to a transaction and the account
+	// identifiers if the transaction is signed
+	TxOperationsAndSignersAccountIdentifiers(ctx context.Context, signed bool, txBytes []byte) (ops []*types.Operation, signers []*types.AccountIdentifier, err error)
+	// ConstructionPayload returns the construction payload given the request
+	ConstructionPayload(ctx context.Context, req *types.ConstructionPayloadsRequest) (resp *types.ConstructionPayloadsResponse, err error)
+	// PreprocessOperationsToOptions returns the options given the preprocess operations
+	PreprocessOperationsToOptions(ctx context.Context, req *types.ConstructionPreprocessRequest) (resp *types.ConstructionPreprocessResponse, err error)
+	// AccountIdentifierFromPublicKey returns the account identifier given the public key
+	AccountIdentifierFromPublicKey(ctx context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error)
+}
+
+// AdaptClient wraps a Client into a ClientV2, the context is not forwarded
+// to the Client methods which do not take one
+func AdaptClient(client Client) ClientV2 {
+	return clientAdapter{client: client}
+}
+
+// UnwrapClient returns the value the optional capabilities of client, such as TxSimulator,
+// are detected on: the wrapped Client if client was built by AdaptClient, client otherwise
+func UnwrapClient(client ClientV2) interface{} {
+	if adapter, ok := client.(clientAdapter); ok {
+		return adapter.client
+	}
+	return client
+}
+
+// clientAdapter implements ClientV2 given a Client
+type clientAdapter struct {
+	client Client
+}
+
+func (a clientAdapter) Bootstrap(_ context.Context) error {
+	return a.client.Bootstrap()
+}
+
+func (a clientAdapter) Ready(_ context.Context) error {
+	return a.client.Ready()
+}
+
+func (a clientAdapter) Balances(ctx context.Context, addr string, height *int64) ([]*types.Amount, error) {
+	return a.client.Balances(ctx, addr, height)
+}
+
+func (a clientAdapter) BlockByHash(ctx context.Context, hash string) (BlockResponse, error) {
+	return a.client.BlockByHash(ctx, hash)
+}
+
+func (a clientAdapter) BlockByHeight(ctx context.Context, height *int64) (BlockResponse, error) {
+	return a.client.BlockByHeight(ctx, height)
+}
+
+func (a clientAdapter) BlockTransactionsByHash(ctx context.Context, hash string) (BlockTransactionsResponse, error) {
+	return a.client.BlockTransactionsByHash(ctx, hash)
+}
+
+func (a clientAdapter) BlockTransactionsByHeight(ctx context.Context, height *int64) (BlockTransactionsResponse, error) {
+	return a.client.BlockTransactionsByHeight(ctx, height)
+}
+
+func (a clientAdapter) GetTx(ctx context.Context, hash string) (*types.Transaction, error) {
+	return a.client.GetTx(ctx, hash)
+}
+
+func (a clientAdapter) Status(ctx context.Context) (*types.SyncStatus, error) {
+	return a.client.Status(ctx)
+}
+
+func (a clientAdapter) PostTx(ctx context.Context, txBytes []byte) (*types.TransactionIdentifier, map[string]interface{}, error) {
+	return a.client.PostTx(ctx, txBytes)
+}
+
+func (a clientAdapter) ConstructionMetadataFromOptions(ctx context.Context, options map[string]interface{}) (map[string]interface{}, error) {
+	return a.client.ConstructionMetadataFromOptions(ctx, options)
+}
+
+func (a clientAdapter) SupportedOperations(_ context.Context) []string {
+	return a.client.SupportedOperations()
+}
+
+func (a clientAdapter) OperationStatuses(_ context.Context) []*types.OperationStatus {
+	return a.client.OperationStatuses()
+}
+
+func (a clientAdapter) Version(_ context.Context) string {
+	return a.client.Version()
+}
+
+func (a clientAdapter) SignedTx(ctx context.Context, txBytes []byte, sigs []*types.Signature) ([]byte, error) {
+	return a.client.SignedTx(ctx, txBytes, sigs)
+}
+
+func (a clientAdapter) TxOperationsAndSignersAccountIdentifiers(_ context.Context, signed bool, txBytes []byte) ([]*types.Operation, []*types.AccountIdentifier, error) {
+	return a.client.TxOperationsAndSignersAccountIdentifiers(signed, txBytes)
+}
+
+func (a clientAdapter) ConstructionPayload(ctx context.Context, req *types.ConstructionPayloadsRequest) (*types.ConstructionPayloadsResponse, error) {
+	return a.client.ConstructionPayload(ctx, req)
+}
+
+func (a clientAdapter) PreprocessOperationsToOptions(ctx context.Context, req *types.ConstructionPreprocessRequest) (*types.ConstructionPreprocessResponse, error) {
+	return a.client.PreprocessOperationsToOptions(ctx, req)
+}
+
+func (a clientAdapter) AccountIdentifierFromPublicKey(_ context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+	return a.client.AccountIdentifierFromPublicKey(pubKey)
+}
@@ -0,0 +1,66 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package types
+
+import (
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// AmountRule defines the constraints on the amount of an operation
+type AmountRule int
+
+const (
+	// AmountOptional means the amount can be either set or not, with any sign
+	AmountOptional AmountRule = iota
+	// AmountForbidden means the operation must not have an amount
+	AmountForbidden
+	// AmountPositive means the amount must be set and greater than zero
+	AmountPositive
+	// AmountNegative means the amount must be set and lower than zero
+	AmountNegative
+	// AmountAny means the amount must be set, with any sign
+	AmountAny
+)
+
+// OperationSchema defines the constraints an operation of a given type must respect
+// in order to be accepted by /construction/preprocess and /construction/payloads
+type OperationSchema struct {
+	// Type is the operation type the schema applies to
+	Type string
+	// RequireAccount defines if the operation must have an account identifier
+	RequireAccount bool
+	// Amount defines the constraints on the operation amount
+	Amount AmountRule
+	// Currencies lists the currencies accepted for the amount, symbol and decimals
+	// must match. If empty any currency is accepted.
+	Currencies []*types.Currency
+	// RequiredMetadata lists the keys that must be present in the operation metadata
+	RequiredMetadata []string
+	// Balanced defines if the amounts of the operations of this type
+	// must sum up to zero for each currency, as in a transfer
+	Balanced bool
+}
+
+// OperationSchemaProvider is an optional capability of OfflineClient, if implemented
+// the construction requests are validated against the schemas before reaching the client.
+// Operations whose type has no schema are rejected.
+type OperationSchemaProvider interface {
+	// OperationSchemas returns the schema of each supported operation type
+	OperationSchemas() []*OperationSchema
+}
@@ -20,6 +20,9 @@ package types
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
 
 	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
@@ -67,13 +70,6 @@ type Client interface {
 	BlockTransactionsByHeight(ctx context.Context, height *int64) (BlockTransactionsResponse, error)
 	// GetTx gets a transaction given its hash
 	GetTx(ctx context.Context, hash string) (*types.Transaction, error)
-	// GetUnconfirmedTx gets an unconfirmed Tx given its hash
-	// NOTE(fdymylja): NOT IMPLEMENTED YET!
-	GetUnconfirmedTx(ctx context.Context, hash string) (*types.Transaction, error)
-	// Mempool returns the list of the current non confirmed transactions
-	Mempool(ctx context.Context) ([]*types.TransactionIdentifier, error)
-	// Peers gets the peers currently connected to the node
-	Peers(ctx context.Context) ([]*types.Peer, error)
 	// Status returns the node status, such as sync data, version etc
 	Status(ctx context.Context) (*types.SyncStatus, error)
 
@@ -81,12 +77,142 @@ type Client interface {
 
 	// PostTx posts txBytes to the node and returns the transaction identifier plus metadata related
 	// to the transaction itself.
-	PostTx(txBytes []byte) (res *types.TransactionIdentifier, meta map[string]interface{}, err error)
+	PostTx(ctx context.Context, txBytes []byte) (res *types.TransactionIdentifier, meta map[string]interface{}, err error)
 	// ConstructionMetadataFromOptions
 	ConstructionMetadataFromOptions(ctx context.Context, options map[string]interface{}) (meta map[string]interface{}, err error)
 	OfflineClient
 }
 
+// PeerProvider is an optional capability of Client, if implemented
+// /network/status reports the peers of the node, otherwise no peer is reported
+type PeerProvider interface {
+	// Peers gets the peers currently connected to the node
+	Peers(ctx context.Context) ([]*types.Peer, error)
+}
+
+// MempoolProvider is an optional capability of Client, if not implemented /mempool returns ErrNotImplemented
+type MempoolProvider interface {
+	// Mempool returns the list of the current non confirmed transactions
+	Mempool(ctx context.Context) ([]*types.TransactionIdentifier, error)
+}
+
+// UnconfirmedTxProvider is an optional capability of Client, if neither it nor MempoolTxsProvider
+// are implemented /mempool/transaction returns ErrNotImplemented
+type UnconfirmedTxProvider interface {
+	// GetUnconfirmedTx gets an unconfirmed Tx given its hash
+	GetUnconfirmedTx(ctx context.Context, hash string) (*types.Transaction, error)
+}
+
+// TxBlockProvider is an optional capability of Client, if implemented /block/transaction checks
+// the requested block against the one returned by TxBlock, otherwise the transaction is looked
+// up among the transactions of the requested block
+type TxBlockProvider interface {
+	// TxBlock returns the identifier of the block the transaction was included in
+	TxBlock(ctx context.Context, hash string) (*types.BlockIdentifier, error)
+}
+
+// MempoolTx is a transaction in the node mempool
+type MempoolTx struct {
+	// Bytes is the signed transaction, as accepted by TxOperationsAndSignersAccountIdentifiers
+	Bytes []byte
+	// Fee is the fee paid by the transaction, nil if unknown
+	Fee []*types.Amount
+}
+
+// MempoolTxsProvider is an optional capability of Client, if implemented /mempool and
+// /mempool/transaction are served from the mempool transactions: they are hashed as
+// in /construction/hash, the ones which cannot be hashed are skipped, and decoded with
+// TxOperationsAndSignersAccountIdentifiers. Every /mempool/transaction request fetches
+// and hashes the whole mempool. It takes precedence over MempoolProvider and UnconfirmedTxProvider.
+type MempoolTxsProvider interface {
+	// MempoolTxs returns the transactions in the node mempool
+	MempoolTxs(ctx context.Context) ([]MempoolTx, error)
+}
+
+// Capability names an optional capability of a client, the capabilities
+// of the client are advertised in /network/options
+type Capability string
+
+const (
+	CapabilityPeers            Capability = "peers"
+	CapabilityMempool          Capability = "mempool"
+	CapabilityUnconfirmedTx    Capability = "unconfirmed_tx"
+	CapabilityMempoolTxs       Capability = "mempool_txs"
+	CapabilityTxBlock          Capability = "tx_block"
+	CapabilityTxInclusion      Capability = "tx_inclusion"
+	CapabilityTxSimulation     Capability = "tx_simulation"
+	CapabilityMinGasPrices     Capability = "min_gas_prices"
+	CapabilityTxHash           Capability = "tx_hash"
+	CapabilityOperationSchemas Capability = "operation_schemas"
+)
+
+// capabilityInterfaces lists the optional capabilities, in advertising order,
+// with the check of the interface implementing them
+var capabilityInterfaces = []struct {
+	capability  Capability
+	implemented func(client interface{}) bool
+}{
+	{CapabilityPeers, func(client interface{}) bool { _, ok := client.(PeerProvider); return ok }},
+	{CapabilityMempool, func(client interface{}) bool { _, ok := client.(MempoolProvider); return ok }},
+	{CapabilityUnconfirmedTx, func(client interface{}) bool { _, ok := client.(UnconfirmedTxProvider); return ok }},
+	{CapabilityMempoolTxs, func(client interface{}) bool { _, ok := client.(MempoolTxsProvider); return ok }},
+	{CapabilityTxBlock, func(client interface{}) bool { _, ok := client.(TxBlockProvider); return ok }},
+	{CapabilityTxInclusion, func(client interface{}) bool { _, ok := client.(TxInclusionWaiter); return ok }},
+	{CapabilityTxSimulation, func(client interface{}) bool { _, ok := client.(TxSimulator); return ok }},
+	{CapabilityMinGasPrices, func(client interface{}) bool { _, ok := client.(MinGasPricesProvider); return ok }},
+	{CapabilityTxHash, func(client interface{}) bool { _, ok := client.(TxHasher); return ok }},
+	{CapabilityOperationSchemas, func(client interface{}) bool { _, ok := client.(OperationSchemaProvider); return ok }},
+}
+
+// CapabilityReporter is implemented by clients, such as decorators, whose methods cover optional
+// capabilities they do not always support, only the capabilities reported as supported are used
+type CapabilityReporter interface {
+	// SupportsCapability returns true if the capability is supported
+	SupportsCapability(c Capability) bool
+}
+
+// HasCapability returns true if client implements the interface of the capability c
+// and, if it is a CapabilityReporter, reports it as supported
+func HasCapability(client interface{}, c Capability) bool {
+	for _, ci := range capabilityInterfaces {
+		if ci.capability != c {
+			continue
+		}
+		if !ci.implemented(client) {
+			return false
+		}
+		if reporter, ok := client.(CapabilityReporter); ok {
+			return reporter.SupportsCapability(c)
+		}
+		return true
+	}
+	return false
+}
+
+// WithCapability returns client if it supports the capability c, nil otherwise. The result
+// is meant to be asserted to the capability interface, so that capabilities not reported
+// by a CapabilityReporter are not used:
+//
+//	hasher, ok := WithCapability(client, CapabilityTxHash).(TxHasher)
+func WithCapability(client interface{}, c Capability) interface{} {
+	if !HasCapability(client, c) {
+		return nil
+	}
+	return client
+}
+
+// Capabilities returns the optional capabilities supported by client,
+// adapted clients must be unwrapped with UnwrapClient
+func Capabilities(client interface{}) []Capability {
+	caps := []Capability{}
+	for _, ci := range capabilityInterfaces {
+		if HasCapability(client, ci.capability) {
+			caps = append(caps, ci.capability)
+		}
+	}
+	return caps
+}
+
 // OfflineClient defines the functionalities supported without having access to the node
 type OfflineClient interface {
 	NetworkInformationProvider
@@ -103,6 +229,117 @@ type OfflineClient interface {
 	AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error)
 }
 
+// TxInclusionWaiter is an optional capability of Client, if implemented
+// ConstructionSubmit can wait for a submitted transaction to be included in a block
+type TxInclusionWaiter interface {
+	// WaitTxInclusion blocks until the transaction identified by hash is included
+	// in a block or the context is done, in which case the context error is returned
+	WaitTxInclusion(ctx context.Context, hash string) (*TxInclusionResult, error)
+}
+
+// TxInclusionResult contains the information regarding the inclusion
+// of a transaction in a block and its execution result
+type TxInclusionResult struct {
+	// Block identifies the block the transaction was included in
+	Block *types.BlockIdentifier
+	// Code is the execution result code, zero means success
+	Code uint32
+	// Codespace is the namespace of Code
+	Codespace string
+	// GasWanted is the gas requested by the transaction
+	GasWanted int64
+	// GasUsed is the gas consumed by the transaction
+	GasUsed int64
+	// Log is the execution log of the transaction
+	Log string
+}
+
+// TxSimulator is an optional capability of Client, if implemented ConstructionMetadata
+// simulates the transaction to estimate its gas consumption
+type TxSimulator interface {
+	// SimulateTx builds the unsigned transaction given the preprocess options and the signers'
+	// public keys, simulates it against the node and returns the gas it consumed
+	SimulateTx(ctx context.Context, options map[string]interface{}, pubKeys []*types.PublicKey) (gasUsed uint64, err error)
+}
+
+// MinGasPricesProvider is an optional capability of Client, if implemented the on-chain
+// minimum gas prices are used to compute the suggested fee when no gas prices are configured
+type MinGasPricesProvider interface {
+	// MinGasPrices returns the minimum gas prices accepted by the chain, amounts
+	// are decimal values expressed in the smallest unit of the currency
+	MinGasPrices(ctx context.Context) ([]*types.Amount, error)
+}
+
+// TxHashFormat defines how transaction hashes are encoded to string
+type TxHashFormat string
+
+const (
+	// TxHashFormatUpperHex encodes hashes as upper case hex, tendermint style
+	TxHashFormatUpperHex TxHashFormat = "upper_hex"
+	// TxHashFormatLowerHex encodes hashes as lower case hex
+	TxHashFormatLowerHex TxHashFormat = "lower_hex"
+	// TxHashFormatPrefixedLowerHex encodes hashes as 0x prefixed lower case hex, ethereum style
+	TxHashFormatPrefixedLowerHex TxHashFormat = "0x_lower_hex"
+)
+
+// Validate checks the format is one of the known formats
+func (f TxHashFormat) Validate() error {
+	switch f {
+	case TxHashFormatUpperHex, TxHashFormatLowerHex, TxHashFormatPrefixedLowerHex:
+		return nil
+	default:
+		return fmt.Errorf("unknown tx hash format %q", string(f))
+	}
+}
+
+// FormatTxHash encodes the hash given the format, clients should use it
+// to build the transaction identifiers returned by the Data API so that they
+// match the ones returned by /construction/hash. The format of a TxHasher is
+// validated when the network is built, unknown formats encode as upper hex.
+func FormatTxHash(format TxHashFormat, hash []byte) string {
+	switch format {
+	case TxHashFormatLowerHex:
+		return hex.EncodeToString(hash)
+	case TxHashFormatPrefixedLowerHex:
+		return "0x" + hex.EncodeToString(hash)
+	default:
+		return strings.ToUpper(hex.EncodeToString(hash))
+	}
+}
+
+// TxHasher is an optional capability of OfflineClient, if implemented /construction/hash
+// uses it instead of the default tendermint hashing (upper case hex of the sha256 of the tx bytes)
+type TxHasher interface {
+	// HashTx returns the raw hash of the signed transaction bytes
+	HashTx(txBytes []byte) ([]byte, error)
+	// TxHashFormat returns the format of the transaction hashes
+	TxHashFormat() TxHashFormat
+}
+
+// CacheFlusher is an optional capability of Client, if implemented
+// the client caches can be flushed through the admin API
+type CacheFlusher interface {
+	// FlushCaches drops every cached value
+	FlushCaches() error
+}
+
+// UpstreamNode describes a node the client sends requests to
+type UpstreamNode struct {
+	// Address identifies the node
+	Address string `json:"address"`
+	// Drained is true if the node does not receive new requests
+	Drained bool `json:"drained"`
+}
+
+// UpstreamNodesManager is an optional capability of Client, if implemented the upstream
+// nodes can be listed and drained through the admin API
+type UpstreamNodesManager interface {
+	// UpstreamNodes lists the nodes the client sends requests to
+	UpstreamNodes() []UpstreamNode
+	// SetNodeDrained drains the node identified by address, or puts it back in service
+	SetNodeDrained(address string, drained bool) error
+}
+
 type BlockTransactionsResponse struct {
 	BlockResponse
 	Transactions []*types.Transaction
@@ -113,6 +350,22 @@ type BlockResponse struct {
 	ParentBlock          *types.BlockIdentifier
 	MillisecondTimestamp int64
 	TxCount              int64
+	// Metadata is the optional block metadata, nil if the client does not provide it
+	Metadata *BlockMetadata
+}
+
+// BlockMetadata contains the block information exposed in the rosetta block metadata
+type BlockMetadata struct {
+	// Proposer is the address of the block proposer
+	Proposer string
+	// AppHash is the application state hash after the previous block
+	AppHash string
+	// EvidenceCount is the number of misbehaviour evidences included in the block
+	EvidenceCount int64
+	// GasUsed is the gas consumed by the transactions of the block
+	GasUsed int64
+	// GasWanted is the gas requested by the transactions of the block
+	GasWanted int64
 }
 
 // API defines the exposed APIs
@@ -0,0 +1,102 @@
+/********************************************************************************
+ 	Apache License 2.0
+ 	Copyright (c) 2020-2021 Tendermint
+ 	Copyright (c) 2022 Zondax AG
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+ *********************************************************************************/
+
+package types
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+func TestFormatTxHash(t *testing.T) {
+	hash := []byte{0xab, 0xcd, 0x01}
+	tests := []struct {
+		format TxHashFormat
+		want   string
+	}{
+		{TxHashFormatUpperHex, "ABCD01"},
+		{TxHashFormatLowerHex, "abcd01"},
+		{TxHashFormatPrefixedLowerHex, "0xabcd01"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if err := tt.format.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			if got := FormatTxHash(tt.format, hash); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxHashFormatValidate(t *testing.T) {
+	for _, format := range []TxHashFormat{"", "upper-hex", "UPPER_HEX", "base64"} {
+		if err := format.Validate(); err == nil {
+			t.Errorf("format %q: expected error", format)
+		}
+	}
+}
+
+// peersAndTxBlock implements the peers and tx block capabilities
+type peersAndTxBlock struct{}
+
+func (peersAndTxBlock) Peers(context.Context) ([]*types.Peer, error) { return nil, nil }
+
+func (peersAndTxBlock) TxBlock(context.Context, string) (*types.BlockIdentifier, error) {
+	return nil, nil
+}
+
+// reportingPeersAndTxBlock reports only the capabilities in supported
+type reportingPeersAndTxBlock struct {
+	peersAndTxBlock
+	supported map[Capability]bool
+}
+
+func (r reportingPeersAndTxBlock) SupportsCapability(c Capability) bool { return r.supported[c] }
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		client interface{}
+		want   []Capability
+	}{
+		{"none", struct{}{}, []Capability{}},
+		{"implemented", peersAndTxBlock{}, []Capability{CapabilityPeers, CapabilityTxBlock}},
+		{"reported", reportingPeersAndTxBlock{supported: map[Capability]bool{CapabilityTxBlock: true}}, []Capability{CapabilityTxBlock}},
+		{"reported but not implemented", reportingPeersAndTxBlock{supported: map[Capability]bool{CapabilityTxHash: true}}, []Capability{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Capabilities(tt.client); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, c := range tt.want {
+				if WithCapability(tt.client, c) == nil {
+					t.Errorf("expected WithCapability to return the client for %s", c)
+				}
+			}
+			if WithCapability(tt.client, CapabilityTxHash) != nil {
+				t.Error("expected WithCapability to return nil for an unsupported capability")
+			}
+		})
+	}
+}